@@ -30,9 +30,7 @@ func main() {
 	host := app.Build()
 	config := host.GetConfiguration()
 	config.RegisterChangeNotificationHandler(func(config configuration.ConfigurationRoot) {
-		if log.Logger != nil {
-			log.Logger.Info("YAY!")
-		}
+		log.Logger.Info("YAY!")
 	})
 
 	host.Run()
@@ -0,0 +1,40 @@
+package audit
+
+import "time"
+
+const (
+	Outcome_Success string = "success"
+	Outcome_Failure string = "failure"
+	Outcome_Denied  string = "denied"
+)
+
+// Event records who performed an action against a resource and what the outcome was
+type Event struct {
+	Id        string                 `json:"id"`
+	Time      time.Time              `json:"time"`
+	Service   string                 `json:"service,omitempty"`
+	Actor     *Actor                 `json:"actor,omitempty"`
+	Action    string                 `json:"action"`
+	Resource  string                 `json:"resource,omitempty"`
+	Decision  *Decision              `json:"decision,omitempty"`
+	Outcome   string                 `json:"outcome"`
+	Reason    string                 `json:"reason,omitempty"`
+	RequestId string                 `json:"requestId,omitempty"`
+	TraceId   string                 `json:"traceId,omitempty"`
+	SourceIp  string                 `json:"sourceIp,omitempty"`
+	Method    string                 `json:"method,omitempty"`
+	Path      string                 `json:"path,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Actor is the principal that performed the action, anonymous requests have no actor
+type Actor struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Decision is the result of the OPA policy that authorized the action
+type Decision struct {
+	Policy  string `json:"policy"`
+	Allowed bool   `json:"allowed"`
+}
@@ -0,0 +1,281 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/projectkeas/sdks-service/authentication"
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/eventPublisher"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+const SERVICE_NAME string = "Audit"
+
+const (
+	// Delivery_BestEffort queues events and writes them in the background, events are dropped when the queue
+	// is full or a sink fails
+	Delivery_BestEffort string = "bestEffort"
+	// Delivery_AtLeastOnce writes events before returning and retries failed sinks, an error is returned
+	// when a sink could not be written to so that the caller can fail the request
+	Delivery_AtLeastOnce string = "atLeastOnce"
+)
+
+type Options struct {
+	// Sinks replace the sinks configured by audit.sinks
+	Sinks []Sink
+	// Service is the name recorded on each event and the source of CloudEvents
+	Service string
+	// TraceId extracts the trace id of the request, no trace id is recorded when nil
+	TraceId func(c *fiber.Ctx) string
+	// Publisher sends the CloudEvents for the cloudevents sink, defaults to eventPublisher.New
+	Publisher eventPublisher.EventPublisherService
+	// Redact removes sensitive values from events before they are written to any of the sinks, events are
+	// written as recorded when nil
+	Redact func(event Event) Event
+}
+
+type settings struct {
+	delivery   string
+	retries    int
+	retryDelay time.Duration
+}
+
+// Service writes audit events to the sinks, it's separate from the application logs so that the audit
+// trail is unaffected by log levels and sampling
+type Service struct {
+	sinks    []Sink
+	service  string
+	traceId  func(c *fiber.Ctx) string
+	redact   func(event Event) Event
+	settings atomic.Value
+	queue    chan Event
+	dropped  uint64
+	closed   bool
+	mutex    *sync.RWMutex
+	wait     *sync.WaitGroup
+}
+
+// New creates the service using the audit.* configuration keys. The sinks and queue size are read once,
+// the delivery mode and retries can be changed at runtime
+func New(config *configuration.ConfigurationRoot, options Options) *Service {
+	service := &Service{
+		sinks:   options.Sinks,
+		service: options.Service,
+		traceId: options.TraceId,
+		redact:  options.Redact,
+		queue:   make(chan Event, config.GetIntValueOrDefault("audit.queueSize", 1000)),
+		mutex:   &sync.RWMutex{},
+		wait:    &sync.WaitGroup{},
+	}
+
+	if len(service.sinks) == 0 {
+		service.sinks = newSinks(config, options)
+	}
+
+	service.settings.Store(getSettings(*config))
+	config.RegisterChangeNotificationHandler(func(config configuration.ConfigurationRoot) {
+		service.settings.Store(getSettings(config))
+	})
+
+	service.wait.Add(1)
+	go service.run()
+
+	return service
+}
+
+func getSettings(config configuration.ConfigurationRoot) *settings {
+	delivery := config.GetStringValueOrDefault("audit.delivery", Delivery_BestEffort)
+	if delivery != Delivery_AtLeastOnce {
+		delivery = Delivery_BestEffort
+	}
+
+	return &settings{
+		delivery:   delivery,
+		retries:    config.GetIntValueOrDefault("audit.retries", 3),
+		retryDelay: config.GetDurationValueOrDefault("audit.retryDelay", 100*time.Millisecond),
+	}
+}
+
+func newSinks(config *configuration.ConfigurationRoot, options Options) []Sink {
+	sinks := []Sink{}
+	for _, name := range config.GetStringSliceValueOrDefault("audit.sinks", []string{"stdout"}) {
+		switch strings.TrimSpace(name) {
+		case "stdout":
+			sinks = append(sinks, NewStdoutSink())
+		case "file":
+			sinks = append(sinks, NewFileSink(config.GetStringValueOrDefault("audit.file.path", "audit.log"), log.FileConfig{
+				MaxSizeMB:  config.GetIntValueOrDefault("audit.file.maxSize", 100),
+				MaxBackups: config.GetIntValueOrDefault("audit.file.maxBackups", 0),
+				MaxAgeDays: config.GetIntValueOrDefault("audit.file.maxAge", 0),
+				Compress:   config.GetBooleanValueOrDefault("audit.file.compress", false),
+			}))
+		case "cloudevents":
+			publisher := options.Publisher
+			if publisher == nil {
+				publisher = eventPublisher.New(config)
+			}
+			sinks = append(sinks, NewCloudEventSink(
+				publisher,
+				config.GetStringValueOrDefault("audit.cloudevents.source", options.Service),
+				config.GetStringValueOrDefault("audit.cloudevents.type", "io.keas.audit"),
+			))
+		default:
+			log.Named(LoggerName).Warn("Ignoring unknown audit sink", zap.String("sink", name))
+		}
+	}
+	return sinks
+}
+
+// Record writes the event to every sink, the id, time and outcome are set when they are empty
+func (service *Service) Record(ctx context.Context, event Event) error {
+	if event.Id == "" {
+		event.Id = utils.UUIDv4()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.Outcome == "" {
+		event.Outcome = Outcome_Success
+	}
+	if event.Service == "" {
+		event.Service = service.service
+	}
+	if service.redact != nil {
+		event = service.redact(event)
+	}
+
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+	if service.closed {
+		return fmt.Errorf("the audit service has been disposed")
+	}
+
+	settings := service.settings.Load().(*settings)
+	if settings.delivery == Delivery_AtLeastOnce {
+		return service.write(ctx, event, settings)
+	}
+
+	select {
+	case service.queue <- event:
+	default:
+		dropped := atomic.AddUint64(&service.dropped, 1)
+		log.Named(LoggerName).Error("Dropped audit event, the queue is full", zap.String("action", event.Action), zap.Uint64("dropped", dropped))
+	}
+	return nil
+}
+
+// RecordRequest records the event with the principal, request id, trace id and source of the request
+func (service *Service) RecordRequest(c *fiber.Ctx, event Event) error {
+	if event.Actor == nil {
+		if principal, found := authentication.GetPrincipal(c); found {
+			event.Actor = &Actor{Id: principal.Id, Type: principal.Type}
+		}
+	}
+	// the values returned by fiber are only valid for the duration of the request so they are copied
+	if event.RequestId == "" {
+		event.RequestId = utils.CopyString(c.GetRespHeader(fiber.HeaderXRequestID))
+	}
+	if event.TraceId == "" && service.traceId != nil {
+		event.TraceId = service.traceId(c)
+	}
+	if event.SourceIp == "" {
+		event.SourceIp = utils.CopyString(c.IP())
+	}
+	if event.Method == "" {
+		event.Method = utils.CopyString(c.Method())
+	}
+	if event.Path == "" {
+		event.Path = utils.CopyString(c.Path())
+	}
+
+	return service.Record(c.UserContext(), event)
+}
+
+// RecordDecision records the outcome of an OPA policy evaluation, it can be used as the OnDecision
+// option of the authentication middleware
+func (service *Service) RecordDecision(c *fiber.Ctx, decision authentication.Decision) error {
+	event := Event{
+		Action:   "authorize",
+		Resource: c.Method() + " " + c.Path(),
+		Decision: &Decision{
+			Policy:  decision.Policy,
+			Allowed: decision.Allowed,
+		},
+		Outcome: Outcome_Success,
+	}
+
+	if decision.Principal != nil {
+		event.Actor = &Actor{Id: decision.Principal.Id, Type: decision.Principal.Type}
+	}
+
+	switch {
+	case decision.Error != nil:
+		event.Outcome = Outcome_Failure
+		event.Reason = decision.Error.Error()
+	case !decision.Allowed:
+		event.Outcome = Outcome_Denied
+	}
+
+	return service.RecordRequest(c, event)
+}
+
+func (service *Service) write(ctx context.Context, event Event, settings *settings) error {
+	failed := []string{}
+	for _, sink := range service.sinks {
+		err := sink.Write(ctx, event)
+		for attempt := 0; err != nil && attempt < settings.retries; attempt++ {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(settings.retryDelay * time.Duration(attempt+1)):
+			}
+			err = sink.Write(ctx, event)
+		}
+
+		if err != nil {
+			log.Named(LoggerName).Error("Unable to write audit event", zap.String("sink", sink.Name()), zap.String("action", event.Action), zap.Error(err))
+			failed = append(failed, sink.Name())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to write audit event %s to %s", event.Id, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (service *Service) run() {
+	defer service.wait.Done()
+	for event := range service.queue {
+		// best effort events are written once, retries would delay the events queued behind them
+		service.write(context.Background(), event, &settings{})
+	}
+}
+
+// Dispose writes the queued events and closes the sinks
+func (service *Service) Dispose() {
+	service.mutex.Lock()
+	if service.closed {
+		service.mutex.Unlock()
+		return
+	}
+	service.closed = true
+	close(service.queue)
+	service.mutex.Unlock()
+
+	service.wait.Wait()
+	for _, sink := range service.sinks {
+		if err := sink.Close(); err != nil {
+			log.Named(LoggerName).Error("Unable to close audit sink", zap.String("sink", sink.Name()), zap.Error(err))
+		}
+	}
+}
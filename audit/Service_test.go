@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap/zapcore"
+)
+
+type recordingSink struct {
+	failures int
+	block    chan bool
+	events   []Event
+	attempts int
+	closed   bool
+	mutex    *sync.Mutex
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{mutex: &sync.Mutex{}}
+}
+
+func (sink *recordingSink) Name() string {
+	return "recording"
+}
+
+func (sink *recordingSink) Write(ctx context.Context, event Event) error {
+	if sink.block != nil {
+		<-sink.block
+	}
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	sink.attempts++
+	if sink.failures > 0 {
+		sink.failures--
+		return errors.New("unavailable")
+	}
+	sink.events = append(sink.events, event)
+	return nil
+}
+
+func (sink *recordingSink) Close() error {
+	sink.closed = true
+	return nil
+}
+
+func (sink *recordingSink) written() []Event {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return append([]Event{}, sink.events...)
+}
+
+func newTestService(values map[string]string, options Options) *Service {
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(configuration.NewInMemoryConfigurationProvider("test", values))
+	return New(builder.Build(), options)
+}
+
+func TestAtLeastOnceRetriesFailedSinks(t *testing.T) {
+	cases := []struct {
+		name     string
+		retries  string
+		failures int
+		fails    bool
+		attempts int
+	}{
+		{name: "succeeds after retrying", retries: "3", failures: 2, attempts: 3},
+		{name: "fails once the retries are exhausted", retries: "1", failures: 2, fails: true, attempts: 2},
+		{name: "succeeds first time", retries: "3", attempts: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := newRecordingSink()
+			sink.failures = tc.failures
+			service := newTestService(map[string]string{
+				"audit.delivery":   Delivery_AtLeastOnce,
+				"audit.retries":    tc.retries,
+				"audit.retryDelay": "1ms",
+			}, Options{Sinks: []Sink{sink}, Service: "orders"})
+			defer service.Dispose()
+
+			err := service.Record(context.Background(), Event{Action: "create"})
+			if (err != nil) != tc.fails {
+				t.Errorf("expected failure to be %t, got %v", tc.fails, err)
+			}
+			if sink.attempts != tc.attempts {
+				t.Errorf("expected %d attempts, got %d", tc.attempts, sink.attempts)
+			}
+
+			if !tc.fails {
+				event := sink.written()[0]
+				if event.Id == "" || event.Time.IsZero() || event.Outcome != Outcome_Success || event.Service != "orders" {
+					t.Errorf("expected the defaults to be set on the event, got %+v", event)
+				}
+			}
+		})
+	}
+}
+
+func TestBestEffortDropsEventsWhenTheQueueIsFull(t *testing.T) {
+	sink := newRecordingSink()
+	sink.block = make(chan bool)
+	service := newTestService(map[string]string{"audit.queueSize": "1"}, Options{Sinks: []Sink{sink}})
+
+	// the first event is taken by the writer, which blocks, and the second fills the queue
+	service.Record(context.Background(), Event{Action: "first"})
+	for len(service.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	service.Record(context.Background(), Event{Action: "second"})
+	if err := service.Record(context.Background(), Event{Action: "dropped"}); err != nil {
+		t.Errorf("expected best effort events to be dropped without an error, got %s", err)
+	}
+	if dropped := atomic.LoadUint64(&service.dropped); dropped != 1 {
+		t.Errorf("expected 1 dropped event, got %d", dropped)
+	}
+
+	close(sink.block)
+	service.Dispose()
+
+	written := sink.written()
+	if len(written) != 2 || written[0].Action != "first" || written[1].Action != "second" {
+		t.Errorf("expected the queued events to be written, got %+v", written)
+	}
+}
+
+func TestDisposeDrainsTheQueue(t *testing.T) {
+	sink := newRecordingSink()
+	service := newTestService(map[string]string{}, Options{Sinks: []Sink{sink}})
+
+	for i := 0; i < 50; i++ {
+		service.Record(context.Background(), Event{Action: "create"})
+	}
+	service.Dispose()
+
+	if count := len(sink.written()); count != 50 {
+		t.Errorf("expected every queued event to be written before disposing, got %d", count)
+	}
+	if !sink.closed {
+		t.Errorf("expected the sinks to be closed")
+	}
+	if err := service.Record(context.Background(), Event{Action: "create"}); err == nil {
+		t.Errorf("expected events recorded after disposing to be rejected")
+	}
+}
+
+func TestEventsAreOnlyRedactedExplicitly(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := newWriterSink("buffer", zapcore.AddSync(buffer), nil)
+	sink := newRecordingSink()
+	service := newTestService(map[string]string{"audit.delivery": Delivery_AtLeastOnce}, Options{
+		Sinks: []Sink{writer, sink},
+		Redact: func(event Event) Event {
+			delete(event.Details, "password")
+			return event
+		},
+	})
+	defer service.Dispose()
+
+	err := service.Record(context.Background(), Event{
+		Action:  "login",
+		Actor:   &Actor{Id: "jane.doe@example.com", Type: "Jwt"},
+		Details: map[string]interface{}{"password": "hunter2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buffer.String(), "jane.doe@example.com") {
+		t.Errorf("expected the actor to be written, got %s", buffer.String())
+	}
+	if strings.Contains(buffer.String(), "hunter2") {
+		t.Errorf("expected the event to be redacted, got %s", buffer.String())
+	}
+	if _, found := sink.written()[0].Details["password"]; found {
+		t.Errorf("expected every sink to receive the redacted event")
+	}
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/projectkeas/sdks-service/eventPublisher"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+// LoggerName is the name audit events are written with so that they can be separated from application logs
+const LoggerName string = "audit"
+
+// Sink writes audit events, an error is returned when the event could not be delivered
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, event Event) error
+	Close() error
+}
+
+// writerSink encodes events as JSON lines. The redaction rules of the application logs aren't applied as
+// they would remove the actor when its id is an email address, use Options.Redact instead
+type writerSink struct {
+	name    string
+	encoder zapcore.Encoder
+	writer  zapcore.WriteSyncer
+	closer  func() error
+	mutex   *sync.Mutex
+}
+
+// NewStdoutSink writes events to stdout alongside the application logs using the audit logger name
+func NewStdoutSink() Sink {
+	return newWriterSink("stdout", zapcore.Lock(os.Stdout), nil)
+}
+
+// NewFileSink writes events to a file that is rotated once it reaches the maximum size
+func NewFileSink(path string, conf log.FileConfig) Sink {
+	maxSize := conf.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = 100
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: conf.MaxBackups,
+		MaxAge:     conf.MaxAgeDays,
+		Compress:   conf.Compress,
+	}
+	return newWriterSink("file", zapcore.AddSync(file), file.Close)
+}
+
+func newWriterSink(name string, writer zapcore.WriteSyncer, closer func() error) *writerSink {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "time"
+	encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	return &writerSink{
+		name:    name,
+		encoder: zapcore.NewJSONEncoder(encoderConfig),
+		writer:  writer,
+		closer:  closer,
+		mutex:   &sync.Mutex{},
+	}
+}
+
+func (sink *writerSink) Name() string {
+	return sink.name
+}
+
+func (sink *writerSink) Write(ctx context.Context, event Event) error {
+	entry := zapcore.Entry{
+		LoggerName: LoggerName,
+		Level:      zapcore.InfoLevel,
+		Time:       event.Time,
+		Message:    "Audit event",
+	}
+
+	buffer, err := sink.encoder.EncodeEntry(entry, []zapcore.Field{zap.Any("audit", event)})
+	if err != nil {
+		return err
+	}
+	defer buffer.Free()
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	// stdout and the rotated files are unbuffered so there's no need to sync them
+	_, err = sink.writer.Write(buffer.Bytes())
+	return err
+}
+
+func (sink *writerSink) Close() error {
+	if sink.closer != nil {
+		return sink.closer()
+	}
+	return nil
+}
+
+// cloudEventSink publishes events to the ingestion service
+type cloudEventSink struct {
+	publisher eventPublisher.EventPublisherService
+	source    string
+	eventType string
+}
+
+// NewCloudEventSink publishes each event as a CloudEvent whose data is the JSON encoded event
+func NewCloudEventSink(publisher eventPublisher.EventPublisherService, source string, eventType string) Sink {
+	return &cloudEventSink{
+		publisher: publisher,
+		source:    source,
+		eventType: eventType,
+	}
+}
+
+func (sink *cloudEventSink) Name() string {
+	return "cloudevents"
+}
+
+func (sink *cloudEventSink) Write(ctx context.Context, event Event) error {
+	cloudEvent := cloudevents.NewEvent()
+	cloudEvent.SetID(event.Id)
+	cloudEvent.SetSource(sink.source)
+	cloudEvent.SetType(sink.eventType)
+	cloudEvent.SetTime(event.Time)
+	if event.Actor != nil {
+		cloudEvent.SetSubject(event.Actor.Id)
+	}
+	if err := cloudEvent.SetData(cloudevents.ApplicationJSON, event); err != nil {
+		return err
+	}
+
+	if !sink.publisher.Publish(cloudEvent) {
+		return fmt.Errorf("the audit event %s was not acknowledged", event.Id)
+	}
+	return nil
+}
+
+func (sink *cloudEventSink) Close() error {
+	return nil
+}
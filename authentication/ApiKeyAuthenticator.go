@@ -0,0 +1,57 @@
+package authentication
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+// ApiKeyAuthenticator validates keys configured under auth.apiKeys in the format name:key,name2:key2 so that
+// keys can be sourced from any provider, typically a Kubernetes Secret
+type ApiKeyAuthenticator struct {
+	keys  map[string]string
+	mutex *sync.RWMutex
+}
+
+func NewApiKeyAuthenticator(config *configuration.ConfigurationRoot) *ApiKeyAuthenticator {
+	authenticator := &ApiKeyAuthenticator{
+		keys:  map[string]string{},
+		mutex: &sync.RWMutex{},
+	}
+
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		keys := map[string]string{}
+		for _, entry := range c.GetStringSliceValueOrDefault("auth.apiKeys", []string{}) {
+			parts := strings.SplitN(entry, ":", 2)
+			if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+				keys[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+
+		authenticator.mutex.Lock()
+		authenticator.keys = keys
+		authenticator.mutex.Unlock()
+	})
+
+	return authenticator
+}
+
+func (authenticator *ApiKeyAuthenticator) Authenticate(key string) (*Principal, bool) {
+	authenticator.mutex.RLock()
+	defer authenticator.mutex.RUnlock()
+
+	// compare against every key so that the time taken doesn't leak which keys exist
+	var principal *Principal
+	for name, value := range authenticator.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(value)) == 1 {
+			principal = &Principal{
+				Id:   name,
+				Type: PrincipalType_ApiKey,
+			}
+		}
+	}
+
+	return principal, principal != nil
+}
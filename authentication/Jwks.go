@@ -0,0 +1,192 @@
+package authentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const jwksRequestTimeout = 10 * time.Second
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// jwksCache holds the keys from a JWKS document loaded from a file or URL. Keys are refreshed after the
+// refresh interval or when a token references an unknown key id, at most once per minimum refresh interval.
+// Only one request fetches the document at a time and a failed fetch is retried by the next request
+type jwksCache struct {
+	source             string
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	keys               map[string]interface{}
+	lastRefresh        time.Time
+	refreshing         bool
+	mutex              *sync.Mutex
+}
+
+func newJwksCache(source string, refreshInterval time.Duration) *jwksCache {
+	return &jwksCache{
+		source:             source,
+		refreshInterval:    refreshInterval,
+		minRefreshInterval: time.Minute,
+		keys:               map[string]interface{}{},
+		mutex:              &sync.Mutex{},
+	}
+}
+
+func (cache *jwksCache) getKey(kid string) (interface{}, error) {
+	cache.mutex.Lock()
+	key, found := cache.keys[kid]
+	sinceRefresh := time.Since(cache.lastRefresh)
+	refresh := !cache.refreshing && ((!found && sinceRefresh > cache.minRefreshInterval) || sinceRefresh > cache.refreshInterval)
+	if refresh {
+		// claim the refresh so that concurrent requests continue to use the current keys while it's fetched
+		cache.refreshing = true
+	}
+	cache.mutex.Unlock()
+
+	if refresh {
+		// the document is fetched without holding the lock so that a slow JWKS endpoint doesn't block
+		// requests signed with keys that are already cached
+		keys, err := cache.load()
+
+		cache.mutex.Lock()
+		cache.refreshing = false
+		if err == nil {
+			cache.keys = keys
+			cache.lastRefresh = time.Now()
+		}
+		cache.mutex.Unlock()
+
+		if err != nil && !found {
+			return nil, err
+		}
+		if err == nil {
+			key, found = keys[kid]
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unable to locate key '%s' in JWKS", kid)
+	}
+
+	return key, nil
+}
+
+func (cache *jwksCache) load() (map[string]interface{}, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	content, err := cache.read()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+func (cache *jwksCache) read() ([]byte, error) {
+	if strings.HasPrefix(cache.source, "http://") || strings.HasPrefix(cache.source, "https://") {
+		client := http.Client{
+			Timeout: jwksRequestTimeout,
+		}
+
+		response, err := client.Get(cache.source)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to load JWKS from '%s', status code: %d", cache.source, response.StatusCode)
+		}
+
+		return ioutil.ReadAll(response.Body)
+	}
+
+	return ioutil.ReadFile(cache.source)
+}
+
+func (jwk jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(jwk.K)
+	}
+
+	return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,172 @@
+package authentication
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+var defaultAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+type jwtSettings struct {
+	parser    *jwt.Parser
+	issuer    string
+	audience  string
+	secret    []byte
+	publicKey interface{}
+	jwks      *jwksCache
+}
+
+// jwtConfig holds the auth.jwt.* values that the settings are built from so that changes to unrelated
+// configuration don't discard the cached JWKS or re-read the public key
+type jwtConfig struct {
+	algorithms      string
+	issuer          string
+	audience        string
+	secret          string
+	publicKeyFile   string
+	jwksSource      string
+	refreshInterval time.Duration
+}
+
+// JwtAuthenticator validates bearer tokens signed with either a shared secret (auth.jwt.secret), a PEM
+// encoded public key (auth.jwt.publicKeyFile) or a JWKS document (auth.jwt.jwks.url or auth.jwt.jwks.file).
+// Tokens must have an expiry (exp)
+type JwtAuthenticator struct {
+	settings atomic.Value
+}
+
+func NewJwtAuthenticator(config *configuration.ConfigurationRoot) *JwtAuthenticator {
+	authenticator := &JwtAuthenticator{}
+	var previous *jwtConfig
+
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		algorithms := c.GetStringSliceValueOrDefault("auth.jwt.algorithms", defaultAlgorithms)
+		current := jwtConfig{
+			algorithms:      strings.Join(algorithms, ","),
+			issuer:          c.GetStringValueOrDefault("auth.jwt.issuer", ""),
+			audience:        c.GetStringValueOrDefault("auth.jwt.audience", ""),
+			secret:          c.GetStringValueOrDefault("auth.jwt.secret", ""),
+			publicKeyFile:   c.GetStringValueOrDefault("auth.jwt.publicKeyFile", ""),
+			jwksSource:      c.GetStringValueOrDefault("auth.jwt.jwks.url", c.GetStringValueOrDefault("auth.jwt.jwks.file", "")),
+			refreshInterval: c.GetDurationValueOrDefault("auth.jwt.jwks.refreshInterval", time.Hour),
+		}
+		if previous != nil && *previous == current {
+			return
+		}
+
+		settings := jwtSettings{
+			parser:   jwt.NewParser(jwt.WithValidMethods(algorithms)),
+			issuer:   current.issuer,
+			audience: current.audience,
+			secret:   []byte(current.secret),
+		}
+
+		var existing jwtSettings
+		if previous != nil {
+			existing = authenticator.settings.Load().(jwtSettings)
+		}
+
+		if current.publicKeyFile != "" {
+			if previous != nil && previous.publicKeyFile == current.publicKeyFile {
+				settings.publicKey = existing.publicKey
+			} else {
+				publicKey, err := readPublicKey(current.publicKeyFile)
+				if err != nil {
+					log.Logger.Error("Unable to load JWT public key", zap.Error(err), zap.String("file", current.publicKeyFile))
+				}
+				settings.publicKey = publicKey
+			}
+		}
+
+		if current.jwksSource != "" {
+			if previous != nil && previous.jwksSource == current.jwksSource && previous.refreshInterval == current.refreshInterval {
+				settings.jwks = existing.jwks
+			} else {
+				settings.jwks = newJwksCache(current.jwksSource, current.refreshInterval)
+			}
+		}
+
+		authenticator.settings.Store(settings)
+		previous = &current
+	})
+
+	return authenticator
+}
+
+func (authenticator *JwtAuthenticator) Authenticate(token string) (*Principal, error) {
+	settings := authenticator.settings.Load().(jwtSettings)
+	claims := jwt.MapClaims{}
+
+	_, err := settings.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if len(settings.secret) == 0 {
+				return nil, fmt.Errorf("no secret has been configured for %s", t.Method.Alg())
+			}
+			return settings.secret, nil
+		}
+
+		if settings.jwks != nil {
+			kid, _ := t.Header["kid"].(string)
+			return settings.jwks.getKey(kid)
+		}
+
+		if settings.publicKey != nil {
+			return settings.publicKey, nil
+		}
+
+		return nil, fmt.Errorf("no public key has been configured for %s", t.Method.Alg())
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the parser only validates exp when it's present, tokens without an expiry would be valid forever
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("the token has no expiry")
+	}
+
+	if settings.issuer != "" && !claims.VerifyIssuer(settings.issuer, true) {
+		return nil, fmt.Errorf("invalid issuer")
+	}
+
+	if settings.audience != "" && !claims.VerifyAudience(settings.audience, true) {
+		return nil, fmt.Errorf("invalid audience")
+	}
+
+	subject, _ := claims["sub"].(string)
+	return &Principal{
+		Id:     subject,
+		Type:   PrincipalType_Jwt,
+		Claims: claims,
+	}, nil
+}
+
+func readPublicKey(file string) (interface{}, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM(content)
+	if err != nil {
+		return jwt.ParseECPublicKeyFromPEM(content)
+	}
+
+	return key, nil
+}
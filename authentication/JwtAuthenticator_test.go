@@ -0,0 +1,196 @@
+package authentication
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+func newTestAuthenticator() *JwtAuthenticator {
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(configuration.NewInMemoryConfigurationProvider("test", map[string]string{
+		"auth.jwt.secret": "secret",
+	}))
+	return NewJwtAuthenticator(builder.Build())
+}
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestJwtRequiresAnExpiry(t *testing.T) {
+	authenticator := newTestAuthenticator()
+
+	_, err := authenticator.Authenticate(sign(t, jwt.MapClaims{"sub": "user"}))
+	if err == nil {
+		t.Errorf("expected a token without an expiry to be rejected")
+	}
+
+	_, err = authenticator.Authenticate(sign(t, jwt.MapClaims{"sub": "user", "exp": time.Now().Add(-time.Minute).Unix()}))
+	if err == nil {
+		t.Errorf("expected an expired token to be rejected")
+	}
+
+	principal, err := authenticator.Authenticate(sign(t, jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Minute).Unix()}))
+	if err != nil {
+		t.Fatalf("expected a token with an expiry to be accepted, got %s", err)
+	}
+	if principal.Id != "user" {
+		t.Errorf("expected the subject to be the principal id, got %q", principal.Id)
+	}
+}
+
+func TestJwksRefreshDoesNotBlockCachedKeys(t *testing.T) {
+	release := make(chan bool)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"keys":[{"kid":"new","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer jwks.Close()
+	defer close(release)
+
+	cache := newJwksCache(jwks.URL, time.Hour)
+	cache.keys["cached"] = []byte("secret")
+	cache.lastRefresh = time.Now().Add(-2 * time.Minute)
+
+	refreshed := make(chan error, 1)
+	go func() {
+		_, err := cache.getKey("new")
+		refreshed <- err
+	}()
+
+	// wait for the refresh to be claimed by the lookup of the unknown key
+	for {
+		cache.mutex.Lock()
+		claimed := cache.refreshing
+		cache.mutex.Unlock()
+		if claimed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	found := make(chan error, 1)
+	go func() {
+		_, err := cache.getKey("cached")
+		found <- err
+	}()
+
+	select {
+	case err := <-found:
+		if err != nil {
+			t.Errorf("expected the cached key to be found, got %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the cached key to be returned while the JWKS was being fetched")
+	}
+
+	release <- true
+	if err := <-refreshed; err != nil {
+		t.Errorf("expected the new key to be found once the JWKS was fetched, got %s", err)
+	}
+}
+
+type mutableProvider struct {
+	values map[string]string
+}
+
+func (provider *mutableProvider) Name() string {
+	return "test"
+}
+
+func (provider *mutableProvider) Type() string {
+	return "InMemory"
+}
+
+func (provider *mutableProvider) TryGetValue(key string) (bool, string) {
+	value, found := provider.values[key]
+	return found, value
+}
+
+func TestJwtSettingsAreOnlyRebuiltWhenChanged(t *testing.T) {
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, []byte(`{"keys":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &mutableProvider{values: map[string]string{
+		"auth.jwt.jwks.file": jwksFile,
+	}}
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(provider)
+	config := builder.Build()
+	authenticator := NewJwtAuthenticator(config)
+
+	current := func() jwtSettings {
+		return authenticator.settings.Load().(jwtSettings)
+	}
+	initial := current()
+
+	provider.values["server.ratelimit.max"] = "10"
+	config.Reload()
+	if current().jwks != initial.jwks || current().parser != initial.parser {
+		t.Errorf("expected the settings to be kept when unrelated configuration changes")
+	}
+
+	provider.values["auth.jwt.issuer"] = "https://issuer"
+	config.Reload()
+	if current().issuer != "https://issuer" {
+		t.Errorf("expected the issuer to be updated, got %q", current().issuer)
+	}
+	if current().jwks != initial.jwks {
+		t.Errorf("expected the JWKS cache to be kept when the source hasn't changed")
+	}
+
+	provider.values["auth.jwt.jwks.refreshInterval"] = "5m"
+	config.Reload()
+	if current().jwks == initial.jwks || current().jwks.refreshInterval != 5*time.Minute {
+		t.Errorf("expected a new JWKS cache when the refresh interval changes")
+	}
+}
+
+func TestJwksLastRefreshIsOnlySetOnSuccess(t *testing.T) {
+	requests := int32(0)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"keys":[{"kid":"new","kty":"oct","k":"c2VjcmV0"}]}`))
+	}))
+	defer jwks.Close()
+
+	cache := newJwksCache(jwks.URL, time.Hour)
+
+	if _, err := cache.getKey("new"); err == nil {
+		t.Fatalf("expected an error when the JWKS can't be fetched")
+	}
+	if !cache.lastRefresh.IsZero() || cache.refreshing {
+		t.Fatalf("expected a failed fetch not to be recorded as a refresh")
+	}
+
+	if _, err := cache.getKey("new"); err != nil {
+		t.Fatalf("expected the failed fetch to be retried, got %s", err)
+	}
+	if cache.lastRefresh.IsZero() {
+		t.Errorf("expected the successful fetch to be recorded")
+	}
+
+	if _, err := cache.getKey("unknown"); err == nil {
+		t.Errorf("expected an unknown key to be rejected")
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("expected unknown keys not to be fetched within the minimum refresh interval, got %d requests", requests)
+	}
+}
@@ -0,0 +1,180 @@
+package authentication
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/opa"
+	"github.com/projectkeas/sdks-service/problems"
+	"github.com/projectkeas/sdks-service/timing"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+type Options struct {
+	// Next skips authentication for the request when it returns true
+	Next func(c *fiber.Ctx) bool
+	// AllowAnonymous continues the chain without a principal when no credentials are supplied
+	AllowAnonymous bool
+	DisableApiKeys bool
+	DisableJwt     bool
+
+	// Policy is the key of an OPA policy (namespace|name) that is evaluated with the request and principal
+	// as input. The request is only allowed when the PolicyDecision output parameter is true
+	Policy         string
+	PolicyDecision string
+	OPA            *opa.OPAService
+
+	// OnDecision is called with the result of every policy evaluation, ie: to audit it. The request fails
+	// when an error is returned
+	OnDecision func(c *fiber.Ctx, decision Decision) error
+
+	// Logger writes the authentication failures, defaults to the global logger
+	Logger *zap.Logger
+}
+
+// Decision is the outcome of evaluating the policy for a request, Error is set when evaluation failed
+type Decision struct {
+	Policy    string
+	Allowed   bool
+	Principal *Principal
+	Error     error
+}
+
+func New(config *configuration.ConfigurationRoot, options Options) fiber.Handler {
+	if options.PolicyDecision == "" {
+		options.PolicyDecision = "allow"
+	}
+	if options.Logger == nil {
+		options.Logger = log.Logger
+	}
+
+	var apiKeys *ApiKeyAuthenticator
+	if !options.DisableApiKeys {
+		apiKeys = NewApiKeyAuthenticator(config)
+	}
+
+	var jwts *JwtAuthenticator
+	if !options.DisableJwt {
+		jwts = NewJwtAuthenticator(config)
+	}
+
+	return func(c *fiber.Ctx) error {
+		if options.Next != nil && options.Next(c) {
+			return c.Next()
+		}
+
+		scheme, credentials := parseAuthorizationHeader(c)
+
+		var principal *Principal
+		switch {
+		case scheme == "apikey" && apiKeys != nil:
+			p, found := apiKeys.Authenticate(credentials)
+			if !found {
+				return unauthorized(c)
+			}
+			principal = p
+		case scheme == "bearer" && jwts != nil:
+			p, err := jwts.Authenticate(credentials)
+			if err != nil {
+				options.Logger.Debug("Unable to validate JWT", zap.Error(err))
+				return unauthorized(c)
+			}
+			principal = p
+		case scheme == "" && options.AllowAnonymous:
+		default:
+			return unauthorized(c)
+		}
+
+		if principal != nil {
+			setPrincipal(c, principal)
+		}
+
+		if options.Policy != "" {
+			stop := timing.Start(c, "policy")
+			allowed, err := authorize(c, options, principal)
+			stop()
+			if options.OnDecision != nil {
+				decisionErr := options.OnDecision(c, Decision{
+					Policy:    options.Policy,
+					Allowed:   allowed,
+					Principal: principal,
+					Error:     err,
+				})
+				if decisionErr != nil {
+					return decisionErr
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return problems.NewForbiddenError("the request was denied by policy")
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+func authorize(c *fiber.Ctx, options Options, principal *Principal) (bool, error) {
+	if options.OPA == nil {
+		return false, fmt.Errorf("no OPA service configured for authorization")
+	}
+
+	headers := map[string]string{}
+	c.Request().Header.VisitAll(func(key []byte, value []byte) {
+		name := strings.ToLower(string(key))
+		if name != "authorization" && name != "x-api-key" {
+			headers[name] = string(value)
+		}
+	})
+
+	query := map[string]string{}
+	c.Request().URI().QueryArgs().VisitAll(func(key []byte, value []byte) {
+		query[string(key)] = string(value)
+	})
+
+	input := map[string]interface{}{
+		"request": map[string]interface{}{
+			"method":  c.Method(),
+			"path":    c.Path(),
+			"headers": headers,
+			"query":   query,
+		},
+		"principal": principal,
+	}
+
+	result, err := options.OPA.EvaluatePolicy(options.Policy, input)
+	if err != nil {
+		return false, err
+	}
+
+	allowed, _ := result[0].Bindings[options.PolicyDecision].(bool)
+	return allowed, nil
+}
+
+func parseAuthorizationHeader(c *fiber.Ctx) (string, string) {
+	authorization := strings.TrimSpace(c.Get(fiber.HeaderAuthorization))
+	if authorization == "" {
+		if key := c.Get("X-API-Key"); key != "" {
+			return "apikey", key
+		}
+		return "", ""
+	}
+
+	parts := strings.SplitN(authorization, " ", 2)
+	if len(parts) != 2 {
+		return strings.ToLower(parts[0]), ""
+	}
+
+	return strings.ToLower(parts[0]), strings.TrimSpace(parts[1])
+}
+
+func unauthorized(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `ApiKey, Bearer`)
+	return problems.NewUnauthorizedError("valid credentials are required to access this resource")
+}
@@ -0,0 +1,25 @@
+package authentication
+
+import "github.com/gofiber/fiber/v2"
+
+const principalKey string = "keas.principal"
+
+const (
+	PrincipalType_ApiKey string = "ApiKey"
+	PrincipalType_Jwt    string = "Jwt"
+)
+
+type Principal struct {
+	Id     string                 `json:"id"`
+	Type   string                 `json:"type"`
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+func GetPrincipal(c *fiber.Ctx) (*Principal, bool) {
+	principal, found := c.Locals(principalKey).(*Principal)
+	return principal, found
+}
+
+func setPrincipal(c *fiber.Ctx, principal *Principal) {
+	c.Locals(principalKey, principal)
+}
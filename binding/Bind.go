@@ -0,0 +1,79 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+const Code_UnsupportedMediaType string = "unsupported_media_type"
+
+// Bind parses the request into T and validates it. Requests with a body are parsed according to their
+// content type (JSON, XML, form or multipart form), requests without a body are parsed from the query string
+func Bind[T any](c *fiber.Ctx) (*T, error) {
+	if len(c.Body()) == 0 {
+		return BindQuery[T](c)
+	}
+	return BindBody[T](c)
+}
+
+// BindBody parses the body of the request into T and validates it
+func BindBody[T any](c *fiber.Ctx) (*T, error) {
+	value := new(T)
+	if err := parseBody(c, value); err != nil {
+		return nil, err
+	}
+	return validated(value)
+}
+
+// BindQuery parses the query string into T using `query` tags and validates it
+func BindQuery[T any](c *fiber.Ctx) (*T, error) {
+	value := new(T)
+	if err := c.QueryParser(value); err != nil {
+		return nil, problems.NewValidationError("the query string could not be parsed").WithCause(err)
+	}
+	return validated(value)
+}
+
+// BindWithSchema validates the raw JSON body against the schema before binding it into T, the schema errors
+// are returned as field errors so the response is the same as a tag validation failure
+func BindWithSchema[T any](c *fiber.Ctx, schema *Schema) (*T, error) {
+	if !strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEApplicationJSON) {
+		return nil, unsupportedMediaType(c)
+	}
+
+	if err := schema.Validate(c.Body()); err != nil {
+		return nil, err
+	}
+
+	return BindBody[T](c)
+}
+
+func parseBody(c *fiber.Ctx, value interface{}) error {
+	err := c.BodyParser(value)
+	if err == nil {
+		return nil
+	}
+
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) && fiberError.Code == fiber.StatusUnprocessableEntity {
+		return unsupportedMediaType(c)
+	}
+
+	return problems.NewValidationError("the request body could not be parsed").WithCause(err)
+}
+
+func validated[T any](value *T) (*T, error) {
+	if err := Validate(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func unsupportedMediaType(c *fiber.Ctx) error {
+	return problems.New(http.StatusUnsupportedMediaType, Code_UnsupportedMediaType, "the content type is not supported").
+		WithDetail("contentType", c.Get(fiber.HeaderContentType))
+}
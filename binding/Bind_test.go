@@ -0,0 +1,194 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+type address struct {
+	City string `json:"city" xml:"city" form:"city" validate:"required"`
+}
+
+type order struct {
+	Name     string   `json:"name" xml:"name" form:"name" query:"name" validate:"required,min=3"`
+	Quantity int      `json:"quantity" xml:"quantity" form:"quantity" query:"quantity" validate:"gte=1"`
+	Address  *address `json:"address,omitempty" xml:"address" form:"-"`
+}
+
+// bind runs the binder inside a request and returns the bound value or the problem rendered for the response
+func bind(t *testing.T, req *http.Request, binder func(c *fiber.Ctx) (interface{}, error)) (interface{}, *problems.Problem) {
+	var bound interface{}
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			problem := problems.From(err)
+			return c.Status(problem.Status).JSON(problem)
+		},
+	})
+	app.All("/orders", func(c *fiber.Ctx) error {
+		value, err := binder(c)
+		if err != nil {
+			return err
+		}
+		bound = value
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	response, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode == fiber.StatusNoContent {
+		return bound, nil
+	}
+
+	problem := &problems.Problem{}
+	body, _ := io.ReadAll(response.Body)
+	if err := json.Unmarshal(body, problem); err != nil {
+		t.Fatalf("expected a problem response, got %d %s", response.StatusCode, body)
+	}
+	return nil, problem
+}
+
+func bindOrder(c *fiber.Ctx) (interface{}, error) {
+	return Bind[order](c)
+}
+
+func newRequest(method string, target string, contentType string, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	return req
+}
+
+func TestBindParsesEachContentType(t *testing.T) {
+	multipartBody := &bytes.Buffer{}
+	writer := multipart.NewWriter(multipartBody)
+	writer.WriteField("name", "widget")
+	writer.WriteField("quantity", "2")
+	writer.Close()
+
+	cases := map[string]*http.Request{
+		"json":      newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, `{"name":"widget","quantity":2}`),
+		"xml":       newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationXML, `<order><name>widget</name><quantity>2</quantity></order>`),
+		"form":      newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationForm, `name=widget&quantity=2`),
+		"multipart": newRequest(fiber.MethodPost, "/orders", writer.FormDataContentType(), multipartBody.String()),
+		"query":     newRequest(fiber.MethodGet, "/orders?name=widget&quantity=2", "", ""),
+	}
+
+	for name, req := range cases {
+		t.Run(name, func(t *testing.T) {
+			bound, problem := bind(t, req, bindOrder)
+			if problem != nil {
+				t.Fatalf("expected the request to be bound, got %+v", problem)
+			}
+			if value := bound.(*order); value.Name != "widget" || value.Quantity != 2 {
+				t.Errorf("expected the values to be bound, got %+v", value)
+			}
+		})
+	}
+}
+
+func TestBindRejectsUnsupportedContentTypes(t *testing.T) {
+	_, problem := bind(t, newRequest(fiber.MethodPost, "/orders", "text/plain", "widget"), bindOrder)
+	if problem == nil || problem.Status != fiber.StatusUnsupportedMediaType || problem.Code != Code_UnsupportedMediaType {
+		t.Errorf("expected a 415 problem, got %+v", problem)
+	}
+}
+
+func TestBindReturnsFieldErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{name: "top level fields", body: `{"name":"ab","quantity":0}`, fields: []string{"name", "quantity"}},
+		{name: "nested fields", body: `{"name":"widget","quantity":1,"address":{}}`, fields: []string{"address.city"}},
+		{name: "malformed body", body: `{"name":`, fields: []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, problem := bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, tc.body), bindOrder)
+			if problem == nil || problem.Status != fiber.StatusBadRequest {
+				t.Fatalf("expected a validation problem, got %+v", problem)
+			}
+
+			fields := []string{}
+			for _, fieldError := range problem.Errors {
+				fields = append(fields, fieldError.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tc.fields, ",") {
+				t.Errorf("expected errors for %v, got %v", tc.fields, fields)
+			}
+		})
+	}
+}
+
+func TestBindValidatesCollections(t *testing.T) {
+	bindOrders := func(c *fiber.Ctx) (interface{}, error) {
+		return Bind[[]order](c)
+	}
+
+	bound, problem := bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, `[{"name":"widget","quantity":1}]`), bindOrders)
+	if problem != nil || len(*bound.(*[]order)) != 1 {
+		t.Fatalf("expected a valid slice to be bound, got %+v", problem)
+	}
+
+	_, problem = bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, `[{"name":"widget","quantity":1},{"name":"ab","quantity":1}]`), bindOrders)
+	if problem == nil || len(problem.Errors) != 1 || problem.Errors[0].Field != "[1].name" {
+		t.Errorf("expected the invalid element to be reported, got %+v", problem)
+	}
+
+	bound, problem = bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, `{"name":"widget"}`), func(c *fiber.Ctx) (interface{}, error) {
+		return Bind[map[string]interface{}](c)
+	})
+	if problem != nil || (*bound.(*map[string]interface{}))["name"] != "widget" {
+		t.Errorf("expected a map to be bound, got %+v", problem)
+	}
+}
+
+func TestBindWithSchema(t *testing.T) {
+	schema := MustLoadSchema(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"},
+			"quantity": {"type": "integer", "minimum": 1}
+		}
+	}`)
+	bindWithSchema := func(c *fiber.Ctx) (interface{}, error) {
+		return BindWithSchema[order](c, schema)
+	}
+
+	bound, problem := bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, `{"name":"widget","quantity":2}`), bindWithSchema)
+	if problem != nil || bound.(*order).Name != "widget" {
+		t.Fatalf("expected a valid document to be bound, got %+v", problem)
+	}
+
+	_, problem = bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationJSON, `{"quantity":0}`), bindWithSchema)
+	if problem == nil || len(problem.Errors) != 2 {
+		t.Fatalf("expected the schema violations to be reported, got %+v", problem)
+	}
+	fields := map[string]string{}
+	for _, fieldError := range problem.Errors {
+		fields[fieldError.Field] = fieldError.Code
+	}
+	if fields["name"] != "required" || fields["quantity"] != "number_gte" {
+		t.Errorf("expected errors for name and quantity, got %v", fields)
+	}
+
+	_, problem = bind(t, newRequest(fiber.MethodPost, "/orders", fiber.MIMEApplicationForm, `name=widget`), bindWithSchema)
+	if problem == nil || problem.Status != fiber.StatusUnsupportedMediaType {
+		t.Errorf("expected schemas to only accept JSON, got %+v", problem)
+	}
+}
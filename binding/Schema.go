@@ -0,0 +1,67 @@
+package binding
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/projectkeas/sdks-service/problems"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Schema is a compiled JSON Schema used to validate payloads that are shared with non-Go services
+type Schema struct {
+	schema *gojsonschema.Schema
+}
+
+func NewSchema(schema string) (*Schema, error) {
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("unable to compile JSON schema: %w", err)
+	}
+	return &Schema{schema: compiled}, nil
+}
+
+func NewSchemaFromFile(path string) (*Schema, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchema(string(contents))
+}
+
+// MustLoadSchema compiles the schema and panics if it's invalid, intended for package level variables
+func MustLoadSchema(schema string) *Schema {
+	compiled, err := NewSchema(schema)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// Validate validates the JSON document against the schema, returning a validation problem with an entry
+// per schema violation
+func (schema *Schema) Validate(document []byte) error {
+	result, err := schema.schema.Validate(gojsonschema.NewBytesLoader(document))
+	if err != nil {
+		return problems.NewValidationError("the request body could not be parsed").WithCause(err)
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	problem := problems.NewValidationError("the request failed schema validation")
+	for _, resultError := range result.Errors() {
+		field := resultError.Field()
+		if property, ok := resultError.Details()["property"].(string); ok && resultError.Type() == "required" {
+			field = property
+			if resultError.Field() != "(root)" {
+				field = resultError.Field() + "." + property
+			}
+		} else if field == "(root)" {
+			field = ""
+		}
+		problem.WithFieldError(field, resultError.Description(), resultError.Type())
+	}
+	return problem
+}
@@ -0,0 +1,139 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+func getValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New()
+		validate.RegisterTagNameFunc(fieldName)
+	})
+	return validate
+}
+
+// RegisterValidation adds a custom validation tag that can be used by every bound struct
+func RegisterValidation(tag string, fn validator.Func) error {
+	return getValidator().RegisterValidation(tag, fn)
+}
+
+// Validate validates a struct using its `validate` tags, returning a validation problem with an entry per
+// invalid field. The elements of slices, arrays and maps are validated individually, other values have no
+// tags to validate
+func Validate(value interface{}) error {
+	var err error
+	root := true
+	switch kind := indirect(reflect.ValueOf(value)).Kind(); kind {
+	case reflect.Struct:
+		err = getValidator().Struct(value)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		root = false
+		err = getValidator().Var(indirect(reflect.ValueOf(value)).Interface(), "dive")
+	default:
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	problem := problems.NewValidationError("the request failed validation")
+	for _, fieldError := range validationErrors {
+		problem.WithFieldError(fieldPath(fieldError, root), fieldMessage(fieldError), fieldError.Tag())
+	}
+	return problem
+}
+
+// fieldName uses the name the field is bound from so that errors match the payload sent by the caller
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	return value
+}
+
+// fieldPath removes the name of the root struct from the namespace, ie: Request.address.city => address.city,
+// the namespace of slices and maps starts with the index, ie: [0].address.city
+func fieldPath(fieldError validator.FieldError, root bool) string {
+	namespace := fieldError.Namespace()
+	if !root {
+		return namespace
+	}
+	if index := strings.Index(namespace, "."); index >= 0 {
+		return namespace[index+1:]
+	}
+	return namespace
+}
+
+func fieldMessage(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", param)
+	case "min":
+		return minMaxMessage(fieldError, "at least", param)
+	case "max":
+		return minMaxMessage(fieldError, "at most", param)
+	case "len":
+		return minMaxMessage(fieldError, "exactly", param)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", param)
+	}
+
+	if param != "" {
+		return fmt.Sprintf("failed the '%s=%s' validation", fieldError.Tag(), param)
+	}
+	return fmt.Sprintf("failed the '%s' validation", fieldError.Tag())
+}
+
+func minMaxMessage(fieldError validator.FieldError, qualifier string, param string) string {
+	switch fieldError.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", qualifier, param)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s items", qualifier, param)
+	}
+	return fmt.Sprintf("must be %s %s", qualifier, param)
+}
@@ -0,0 +1,127 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Version, Commit and BuildTime override the values read from the build info, set them with:
+// -ldflags "-X github.com/projectkeas/sdks-service/buildinfo.Version=1.2.3"
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+const namespaceFile string = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+type Info struct {
+	Module     string         `json:"module,omitempty"`
+	Version    string         `json:"version,omitempty"`
+	Commit     string         `json:"commit,omitempty"`
+	CommitTime string         `json:"commitTime,omitempty"`
+	Modified   bool           `json:"modified,omitempty"`
+	BuildTime  string         `json:"buildTime,omitempty"`
+	GoVersion  string         `json:"goVersion"`
+	Kubernetes KubernetesInfo `json:"kubernetes"`
+}
+
+// KubernetesInfo is read from the POD_NAME, POD_NAMESPACE, POD_IP and NODE_NAME environment variables which
+// should be populated using the downward API
+type KubernetesInfo struct {
+	PodName   string `json:"podName,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	PodIP     string `json:"podIP,omitempty"`
+	NodeName  string `json:"nodeName,omitempty"`
+}
+
+var (
+	info     Info
+	infoOnce sync.Once
+)
+
+// Get returns the build and runtime metadata, it's read once as it can't change whilst the process is running
+func Get() Info {
+	infoOnce.Do(func() {
+		info = read()
+	})
+	return info
+}
+
+func read() Info {
+	result := Info{
+		GoVersion: runtime.Version(),
+		Kubernetes: KubernetesInfo{
+			PodName:   os.Getenv("POD_NAME"),
+			Namespace: os.Getenv("POD_NAMESPACE"),
+			PodIP:     os.Getenv("POD_IP"),
+			NodeName:  os.Getenv("NODE_NAME"),
+		},
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		result.Module = build.Main.Path
+		if build.Main.Version != "" && build.Main.Version != "(devel)" {
+			result.Version = build.Main.Version
+		}
+
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				result.Commit = setting.Value
+			case "vcs.time":
+				result.CommitTime = setting.Value
+			case "vcs.modified":
+				result.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if Version != "" {
+		result.Version = Version
+	}
+	if Commit != "" {
+		result.Commit = Commit
+	}
+	result.BuildTime = BuildTime
+
+	// fall back to the values Kubernetes provides to every pod when the downward API isn't configured
+	if result.Kubernetes.PodName == "" && result.Kubernetes.Namespace != "" {
+		result.Kubernetes.PodName = os.Getenv("HOSTNAME")
+	}
+	if result.Kubernetes.Namespace == "" {
+		if namespace, err := os.ReadFile(namespaceFile); err == nil {
+			result.Kubernetes.Namespace = strings.TrimSpace(string(namespace))
+			if result.Kubernetes.PodName == "" {
+				result.Kubernetes.PodName = os.Getenv("HOSTNAME")
+			}
+		}
+	}
+
+	return result
+}
+
+// Attributes returns the metadata using the OpenTelemetry semantic conventions so that it can be attached
+// to the resource of traces and metrics
+func (info Info) Attributes(serviceName string) map[string]string {
+	attributes := map[string]string{
+		"service.name": serviceName,
+	}
+
+	add := func(key string, value string) {
+		if value != "" {
+			attributes[key] = value
+		}
+	}
+	add("service.version", info.Version)
+	add("vcs.revision", info.Commit)
+	add("k8s.pod.name", info.Kubernetes.PodName)
+	add("k8s.namespace.name", info.Kubernetes.Namespace)
+	add("k8s.node.name", info.Kubernetes.NodeName)
+	add("k8s.pod.ip", info.Kubernetes.PodIP)
+
+	return attributes
+}
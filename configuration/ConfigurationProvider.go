@@ -13,3 +13,13 @@ type ObservableConfigurationProvider interface {
 
 	getChannel() chan map[string]string
 }
+
+// KubernetesResourceProvider is implemented by the providers for ConfigMaps and Secrets so that the health
+// checks can report whether the resource exists
+type KubernetesResourceProvider interface {
+	Name() string
+	Type() string
+	TryGetValue(key string) (bool, string)
+
+	ResourceExists() bool
+}
@@ -3,7 +3,9 @@ package configuration
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 type ConfigurationRoot struct {
@@ -35,6 +37,18 @@ func (config *ConfigurationRoot) GetStringValueOrDefault(key string, defaultValu
 	return defaultValue
 }
 
+// GetValueSource returns the provider that the value for the key is read from
+func (config *ConfigurationRoot) GetValueSource(key string) (ConfigurationProvider, bool) {
+	for _, provider := range config.Providers {
+		found, _ := provider.TryGetValue(key)
+		if found {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
+
 func (config *ConfigurationRoot) GetIntValueOrDefault(key string, defaultValue int) int {
 	for _, provider := range config.Providers {
 		found, value := provider.TryGetValue(key)
@@ -63,12 +77,56 @@ func (config *ConfigurationRoot) GetBooleanValueOrDefault(key string, defaultVal
 	return defaultValue
 }
 
+func (config *ConfigurationRoot) GetDurationValueOrDefault(key string, defaultValue time.Duration) time.Duration {
+	for _, provider := range config.Providers {
+		found, value := provider.TryGetValue(key)
+		if found {
+			d, err := time.ParseDuration(value)
+			if err == nil {
+				return d
+			}
+		}
+	}
+
+	return defaultValue
+}
+
+// GetStringSliceValueOrDefault splits a comma separated value into its trimmed, non-empty parts
+func (config *ConfigurationRoot) GetStringSliceValueOrDefault(key string, defaultValue []string) []string {
+	for _, provider := range config.Providers {
+		found, value := provider.TryGetValue(key)
+		if found {
+			result := []string{}
+			for _, part := range strings.Split(value, ",") {
+				part = strings.TrimSpace(part)
+				if part != "" {
+					result = append(result, part)
+				}
+			}
+			return result
+		}
+	}
+
+	return defaultValue
+}
+
 func (config *ConfigurationRoot) RegisterChangeNotificationHandler(handler func(ConfigurationRoot)) *ConfigurationRoot {
 	config.onChangeHandlers = append(config.onChangeHandlers, handler)
 	handler(*config)
 	return config
 }
 
+// Reload synchronously notifies the change handlers, this is used when the values of a provider that
+// cannot be observed have been changed
+func (config *ConfigurationRoot) Reload() {
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for _, handler := range config.onChangeHandlers {
+		handler(*config)
+	}
+}
+
 func observeChanges(config *ConfigurationRoot, provider ObservableConfigurationProvider) {
 	for {
 		func() {
@@ -49,6 +49,10 @@ func (provider *KubernetesConfigMapConfigurationProvider) Type() string {
 	return "KubernetesConfigMap"
 }
 
+func (provider *KubernetesConfigMapConfigurationProvider) ResourceExists() bool {
+	return provider.Exists
+}
+
 func (provider *KubernetesConfigMapConfigurationProvider) TryGetValue(key string) (bool, string) {
 
 	data, found := provider.data[key]
@@ -68,14 +72,14 @@ func onNewConfigMap(provider *KubernetesConfigMapConfigurationProvider) func(new
 	return func(newConfigMap interface{}) {
 		configMap, successfulCast := newConfigMap.(*types.ConfigMap)
 		if successfulCast && configMap.Name == provider.name {
-			if addOrUpdateConfigMap(provider, configMap) && log.Logger != nil {
-				log.Logger.Debug("ConfigMap added", zap.Any("configMap", map[string]string{
+			if addOrUpdateConfigMap(provider, configMap) {
+				log.Named("configuration").Debug("ConfigMap added", zap.Any("configMap", map[string]string{
 					"name":      configMap.Name,
 					"namespace": configMap.Namespace,
 				}))
 			}
 		} else if !successfulCast {
-			log.Logger.Error("could not cast config map")
+			log.Named("configuration").Error("could not cast config map")
 		}
 	}
 }
@@ -84,14 +88,14 @@ func onUpdatedConfigMap(provider *KubernetesConfigMapConfigurationProvider) func
 	return func(oldConfigMap interface{}, newConfigMap interface{}) {
 		configMap, successfulCast := newConfigMap.(*types.ConfigMap)
 		if successfulCast && configMap.Name == provider.name {
-			if addOrUpdateConfigMap(provider, configMap) && log.Logger != nil {
-				log.Logger.Debug("ConfigMap updated", zap.Any("configMap", map[string]string{
+			if addOrUpdateConfigMap(provider, configMap) {
+				log.Named("configuration").Debug("ConfigMap updated", zap.Any("configMap", map[string]string{
 					"name":      configMap.Name,
 					"namespace": configMap.Namespace,
 				}))
 			}
 		} else if !successfulCast {
-			log.Logger.Error("could not cast config map")
+			log.Named("configuration").Error("could not cast config map")
 		}
 	}
 }
@@ -116,15 +120,13 @@ func onDeletedConfigMap(provider *KubernetesConfigMapConfigurationProvider) func
 		if successfulCast && configMap.Name == provider.name {
 			provider.data = map[string]string{}
 			provider.Exists = false
-			if log.Logger != nil {
-				log.Logger.Debug("ConfigMap deleted", zap.Any("configMap", map[string]string{
-					"name":      configMap.Name,
-					"namespace": configMap.Namespace,
-				}))
-			}
+			log.Named("configuration").Debug("ConfigMap deleted", zap.Any("configMap", map[string]string{
+				"name":      configMap.Name,
+				"namespace": configMap.Namespace,
+			}))
 			provider.updateChannel <- provider.data
 		} else if !successfulCast {
-			log.Logger.Error("could not cast config map")
+			log.Named("configuration").Error("could not cast config map")
 		}
 	}
 }
@@ -49,6 +49,10 @@ func (provider *KubernetesSecretConfigurationProvider) Type() string {
 	return "KubernetesSecret"
 }
 
+func (provider *KubernetesSecretConfigurationProvider) ResourceExists() bool {
+	return provider.Exists
+}
+
 func (provider *KubernetesSecretConfigurationProvider) TryGetValue(key string) (bool, string) {
 
 	data, found := provider.data[key]
@@ -68,14 +72,14 @@ func onNewSecret(provider *KubernetesSecretConfigurationProvider) func(newSecret
 	return func(newSecret interface{}) {
 		secret, successfulCast := newSecret.(*types.Secret)
 		if successfulCast && secret.Name == provider.name {
-			if addOrUpdateSecret(provider, secret) && log.Logger != nil {
-				log.Logger.Debug("Secret added", zap.Any("secret", map[string]string{
+			if addOrUpdateSecret(provider, secret) {
+				log.Named("configuration").Debug("Secret added", zap.Any("secret", map[string]string{
 					"name":      secret.Name,
 					"namespace": secret.Namespace,
 				}))
 			}
 		} else if !successfulCast {
-			log.Logger.Error("could not cast config map")
+			log.Named("configuration").Error("could not cast config map")
 		}
 	}
 }
@@ -84,14 +88,14 @@ func onUpdatedSecret(provider *KubernetesSecretConfigurationProvider) func(oldSe
 	return func(oldSecret interface{}, newSecret interface{}) {
 		secret, successfulCast := newSecret.(*types.Secret)
 		if successfulCast && secret.Name == provider.name {
-			if addOrUpdateSecret(provider, secret) && log.Logger != nil {
-				log.Logger.Debug("Secret updated", zap.Any("secret", map[string]string{
+			if addOrUpdateSecret(provider, secret) {
+				log.Named("configuration").Debug("Secret updated", zap.Any("secret", map[string]string{
 					"name":      secret.Name,
 					"namespace": secret.Namespace,
 				}))
 			}
 		} else if !successfulCast {
-			log.Logger.Error("could not cast config map")
+			log.Named("configuration").Error("could not cast config map")
 		}
 	}
 }
@@ -117,6 +121,7 @@ func addOrUpdateSecret(provider *KubernetesSecretConfigurationProvider, secret *
 		provider.data = map[string]string{}
 	}
 
+	registerSensitiveValues(provider)
 	provider.resourceVersion = secret.ResourceVersion
 	provider.Exists = true
 	provider.updateChannel <- provider.data
@@ -129,15 +134,23 @@ func onDeletedSecret(provider *KubernetesSecretConfigurationProvider) func(delet
 		if successfulCast && secret.Name == provider.name {
 			provider.data = map[string]string{}
 			provider.Exists = false
-			if log.Logger != nil {
-				log.Logger.Debug("Secret deleted", zap.Any("secret", map[string]string{
-					"name":      secret.Name,
-					"namespace": secret.Namespace,
-				}))
-			}
+			registerSensitiveValues(provider)
+			log.Named("configuration").Debug("Secret deleted", zap.Any("secret", map[string]string{
+				"name":      secret.Name,
+				"namespace": secret.Namespace,
+			}))
 			provider.updateChannel <- provider.data
 		} else if !successfulCast {
-			log.Logger.Error("could not cast config map")
+			log.Named("configuration").Error("could not cast config map")
 		}
 	}
 }
+
+// registerSensitiveValues ensures that the values of the secret are redacted should they be logged
+func registerSensitiveValues(provider *KubernetesSecretConfigurationProvider) {
+	values := []string{}
+	for _, value := range provider.data {
+		values = append(values, value)
+	}
+	log.SetSensitiveValues("KubernetesSecret/"+provider.name, values)
+}
@@ -0,0 +1,167 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+type registration struct {
+	serviceType  reflect.Type
+	lifetime     Lifetime
+	factory      reflect.Value
+	dependencies []reflect.Type
+	instance     interface{}
+	hasInstance  bool
+}
+
+type Builder struct {
+	registrations map[reflect.Type]*registration
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		registrations: map[reflect.Type]*registration{},
+	}
+}
+
+// Clone returns a builder with the same registrations that can be changed without affecting this builder
+func (builder *Builder) Clone() *Builder {
+	clone := NewBuilder()
+	for key, value := range builder.registrations {
+		clone.registrations[key] = value
+	}
+	return clone
+}
+
+// Register adds a factory for T. The factory must be a function whose parameters are the services it
+// depends upon and returns either T or (T, error):
+//
+//	container.Register[*Repository](builder, container.Lifetime_Singleton, func(config *configuration.ConfigurationRoot) (*Repository, error) { ... })
+//
+// Registering the same type again replaces the previous registration
+func Register[T any](builder *Builder, lifetime Lifetime, factory interface{}) *Builder {
+	serviceType := typeOf[T]()
+	factoryValue := reflect.ValueOf(factory)
+	factoryType := factoryValue.Type()
+
+	if factoryType.Kind() != reflect.Func {
+		panic(fmt.Sprintf("factory for %s must be a function, got %s", serviceType, factoryType))
+	}
+
+	validReturn := factoryType.NumOut() == 1 || (factoryType.NumOut() == 2 && factoryType.Out(1) == errorType)
+	if !validReturn || !factoryType.Out(0).AssignableTo(serviceType) {
+		panic(fmt.Sprintf("factory for %s must return %s or (%s, error), got %s", serviceType, serviceType, serviceType, factoryType))
+	}
+
+	dependencies := []reflect.Type{}
+	for i := 0; i < factoryType.NumIn(); i++ {
+		dependencies = append(dependencies, factoryType.In(i))
+	}
+
+	builder.registrations[serviceType] = &registration{
+		serviceType:  serviceType,
+		lifetime:     lifetime,
+		factory:      factoryValue,
+		dependencies: dependencies,
+	}
+
+	return builder
+}
+
+// RegisterInstance adds an existing instance as a singleton. Instances are owned by the caller and are not
+// disposed of by the container
+func RegisterInstance[T any](builder *Builder, instance T) *Builder {
+	serviceType := typeOf[T]()
+	builder.registrations[serviceType] = &registration{
+		serviceType: serviceType,
+		lifetime:    Lifetime_Singleton,
+		instance:    instance,
+		hasInstance: true,
+	}
+
+	return builder
+}
+
+// Build validates that every dependency has been registered, that there are no circular dependencies and
+// that singletons do not capture request scoped services
+func (builder *Builder) Build() (*Container, error) {
+	registrations := map[reflect.Type]*registration{}
+	for key, value := range builder.registrations {
+		registrations[key] = value
+	}
+
+	visited := map[reflect.Type]bool{}
+	requiresScope := map[reflect.Type]bool{}
+
+	var visit func(reg *registration, path []reflect.Type) error
+	visit = func(reg *registration, path []reflect.Type) error {
+		for _, t := range path {
+			if t == reg.serviceType {
+				return fmt.Errorf("circular dependency detected: %s", formatPath(append(path, reg.serviceType)))
+			}
+		}
+
+		if visited[reg.serviceType] {
+			return nil
+		}
+
+		path = append(path, reg.serviceType)
+		for _, dependencyType := range reg.dependencies {
+			dependency, found := registrations[dependencyType]
+			if !found {
+				return fmt.Errorf("unable to locate dependency %s for %s", dependencyType, reg.serviceType)
+			}
+
+			err := visit(dependency, path)
+			if err != nil {
+				return err
+			}
+
+			if requiresScope[dependencyType] {
+				if reg.lifetime == Lifetime_Singleton {
+					return fmt.Errorf("singleton %s cannot depend on request scoped service %s", reg.serviceType, dependencyType)
+				}
+				requiresScope[reg.serviceType] = true
+			}
+		}
+
+		if reg.lifetime == Lifetime_Request {
+			requiresScope[reg.serviceType] = true
+		}
+
+		visited[reg.serviceType] = true
+		return nil
+	}
+
+	for _, reg := range registrations {
+		err := visit(reg, []reflect.Type{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	container := &Container{
+		registrations: registrations,
+		singletons:    map[reflect.Type]*lazyInstance{},
+		mutex:         &sync.Mutex{},
+	}
+	container.root = newScope(container, false)
+
+	return container, nil
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func formatPath(path []reflect.Type) string {
+	parts := []string{}
+	for _, t := range path {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, " -> ")
+}
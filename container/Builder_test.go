@@ -0,0 +1,115 @@
+package container
+
+import (
+	"strings"
+	"testing"
+)
+
+// the services have a field so that each instance has a distinct address
+type config struct{ id int }
+type repository struct{ id int }
+type handler struct{ id int }
+type session struct{ id int }
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(builder *Builder)
+		err      string
+	}{
+		{
+			name: "resolvable graph",
+			register: func(builder *Builder) {
+				RegisterInstance(builder, &config{})
+				Register[*repository](builder, Lifetime_Singleton, func(*config) *repository { return &repository{} })
+				Register[*session](builder, Lifetime_Request, func(*repository) *session { return &session{} })
+				Register[*handler](builder, Lifetime_Transient, func(*session, *repository) *handler { return &handler{} })
+			},
+		},
+		{
+			name: "missing dependency",
+			register: func(builder *Builder) {
+				Register[*repository](builder, Lifetime_Singleton, func(*config) *repository { return &repository{} })
+			},
+			err: "unable to locate dependency *container.config for *container.repository",
+		},
+		{
+			name: "circular dependency",
+			register: func(builder *Builder) {
+				Register[*config](builder, Lifetime_Singleton, func(*handler) *config { return &config{} })
+				Register[*repository](builder, Lifetime_Singleton, func(*config) *repository { return &repository{} })
+				Register[*handler](builder, Lifetime_Transient, func(*repository) *handler { return &handler{} })
+			},
+			err: "circular dependency detected",
+		},
+		{
+			name: "self dependency",
+			register: func(builder *Builder) {
+				Register[*config](builder, Lifetime_Transient, func(*config) *config { return &config{} })
+			},
+			err: "circular dependency detected: *container.config -> *container.config",
+		},
+		{
+			name: "singleton depends on request scoped",
+			register: func(builder *Builder) {
+				Register[*session](builder, Lifetime_Request, func() *session { return &session{} })
+				Register[*repository](builder, Lifetime_Singleton, func(*session) *repository { return &repository{} })
+			},
+			err: "singleton *container.repository cannot depend on request scoped service *container.session",
+		},
+		{
+			name: "singleton depends on request scoped through a transient",
+			register: func(builder *Builder) {
+				Register[*session](builder, Lifetime_Request, func() *session { return &session{} })
+				Register[*handler](builder, Lifetime_Transient, func(*session) *handler { return &handler{} })
+				Register[*repository](builder, Lifetime_Singleton, func(*handler) *repository { return &repository{} })
+			},
+			err: "singleton *container.repository cannot depend on request scoped service *container.handler",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := NewBuilder()
+			test.register(builder)
+
+			container, err := builder.Build()
+			if test.err == "" {
+				if err != nil || container == nil {
+					t.Fatalf("expected the container to be built, got %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected an error containing %q, got %v", test.err, err)
+			}
+			if container != nil {
+				t.Errorf("expected no container when the build fails")
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsInvalidFactories(t *testing.T) {
+	tests := []struct {
+		name    string
+		factory interface{}
+	}{
+		{name: "not a function", factory: &repository{}},
+		{name: "wrong return type", factory: func() *config { return nil }},
+		{name: "second return is not an error", factory: func() (*repository, bool) { return nil, false }},
+		{name: "no return", factory: func() {}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected Register to panic")
+				}
+			}()
+			Register[*repository](NewBuilder(), Lifetime_Singleton, test.factory)
+		})
+	}
+}
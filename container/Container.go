@@ -0,0 +1,214 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Disposable matches server.Disposable, any service created by the container that implements it is disposed
+// of when the scope that created it is disposed
+type Disposable interface {
+	Dispose()
+}
+
+type Resolver interface {
+	resolve(serviceType reflect.Type) (interface{}, error)
+}
+
+type Container struct {
+	registrations map[reflect.Type]*registration
+	singletons    map[reflect.Type]*lazyInstance
+	root          *Scope
+	mutex         *sync.Mutex
+}
+
+// Scope tracks the request scoped and transient services created within it
+type Scope struct {
+	container   *Container
+	isRequest   bool
+	instances   map[reflect.Type]*lazyInstance
+	disposables []Disposable
+	disposed    bool
+	mutex       *sync.Mutex
+}
+
+type lazyInstance struct {
+	created bool
+	value   interface{}
+	mutex   sync.Mutex
+}
+
+func Resolve[T any](resolver Resolver) (T, error) {
+	var result T
+
+	serviceType := typeOf[T]()
+	instance, err := resolver.resolve(serviceType)
+	if err != nil {
+		return result, err
+	}
+
+	result, ok := instance.(T)
+	if !ok {
+		return result, fmt.Errorf("resolved service of type %T is not assignable to %s", instance, serviceType)
+	}
+
+	return result, nil
+}
+
+func MustResolve[T any](resolver Resolver) T {
+	result, err := Resolve[T](resolver)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func (container *Container) NewScope() *Scope {
+	return newScope(container, true)
+}
+
+func (container *Container) IsRegistered(serviceType reflect.Type) bool {
+	_, found := container.registrations[serviceType]
+	return found
+}
+
+// Dispose disposes of every singleton and transient created from the container in the reverse order to
+// which they were created
+func (container *Container) Dispose() {
+	container.root.Dispose()
+}
+
+func (container *Container) resolve(serviceType reflect.Type) (interface{}, error) {
+	return container.root.resolve(serviceType)
+}
+
+func (container *Container) singleton(serviceType reflect.Type) *lazyInstance {
+	container.mutex.Lock()
+	defer container.mutex.Unlock()
+
+	instance, found := container.singletons[serviceType]
+	if !found {
+		instance = &lazyInstance{}
+		container.singletons[serviceType] = instance
+	}
+
+	return instance
+}
+
+func newScope(container *Container, isRequest bool) *Scope {
+	return &Scope{
+		container: container,
+		isRequest: isRequest,
+		instances: map[reflect.Type]*lazyInstance{},
+		mutex:     &sync.Mutex{},
+	}
+}
+
+func (scope *Scope) Dispose() {
+	scope.mutex.Lock()
+	disposables := scope.disposables
+	scope.disposables = nil
+	scope.disposed = true
+	scope.mutex.Unlock()
+
+	for i := len(disposables) - 1; i >= 0; i-- {
+		disposables[i].Dispose()
+	}
+}
+
+func (scope *Scope) resolve(serviceType reflect.Type) (interface{}, error) {
+	reg, found := scope.container.registrations[serviceType]
+	if !found {
+		return nil, fmt.Errorf("unable to locate service %s", serviceType)
+	}
+
+	switch reg.lifetime {
+	case Lifetime_Singleton:
+		if reg.hasInstance {
+			return reg.instance, nil
+		}
+
+		root := scope.container.root
+		return scope.container.singleton(serviceType).get(func() (interface{}, error) {
+			return root.construct(reg)
+		})
+	case Lifetime_Request:
+		if !scope.isRequest {
+			return nil, fmt.Errorf("request scoped service %s cannot be resolved outside of a scope", serviceType)
+		}
+
+		return scope.scoped(serviceType).get(func() (interface{}, error) {
+			return scope.construct(reg)
+		})
+	}
+
+	return scope.construct(reg)
+}
+
+func (scope *Scope) scoped(serviceType reflect.Type) *lazyInstance {
+	scope.mutex.Lock()
+	defer scope.mutex.Unlock()
+
+	instance, found := scope.instances[serviceType]
+	if !found {
+		instance = &lazyInstance{}
+		scope.instances[serviceType] = instance
+	}
+
+	return instance
+}
+
+func (scope *Scope) construct(reg *registration) (interface{}, error) {
+	args := []reflect.Value{}
+	for _, dependencyType := range reg.dependencies {
+		dependency, err := scope.resolve(dependencyType)
+		if err != nil {
+			return nil, err
+		}
+
+		value := reflect.New(dependencyType).Elem()
+		if dependency != nil {
+			value.Set(reflect.ValueOf(dependency))
+		}
+		args = append(args, value)
+	}
+
+	results := reg.factory.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return nil, fmt.Errorf("unable to create %s: %w", reg.serviceType, results[1].Interface().(error))
+	}
+
+	instance := results[0].Interface()
+
+	if disposable, ok := instance.(Disposable); ok {
+		scope.mutex.Lock()
+		defer scope.mutex.Unlock()
+
+		if scope.disposed {
+			disposable.Dispose()
+			return nil, fmt.Errorf("unable to create %s as the scope has been disposed", reg.serviceType)
+		}
+		scope.disposables = append(scope.disposables, disposable)
+	}
+
+	return instance, nil
+}
+
+func (instance *lazyInstance) get(create func() (interface{}, error)) (interface{}, error) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	if instance.created {
+		return instance.value, nil
+	}
+
+	value, err := create()
+	if err != nil {
+		return nil, err
+	}
+
+	instance.value = value
+	instance.created = true
+	return value, nil
+}
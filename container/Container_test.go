@@ -0,0 +1,240 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type disposable struct {
+	name     string
+	disposed *[]string
+}
+
+func (d *disposable) Dispose() {
+	*d.disposed = append(*d.disposed, d.name)
+}
+
+type first struct{ *disposable }
+type second struct{ *disposable }
+type third struct{ *disposable }
+
+func TestResolutionIsLazy(t *testing.T) {
+	calls := map[string]int{}
+	builder := NewBuilder()
+	Register[*config](builder, Lifetime_Singleton, func() *config {
+		calls["singleton"]++
+		return &config{}
+	})
+	Register[*session](builder, Lifetime_Request, func() *session {
+		calls["request"]++
+		return &session{}
+	})
+	Register[*handler](builder, Lifetime_Transient, func() *handler {
+		calls["transient"]++
+		return &handler{}
+	})
+
+	container, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("expected no services to be created by Build, got %v", calls)
+	}
+
+	scopes := []*Scope{container.NewScope(), container.NewScope()}
+	for _, scope := range scopes {
+		for i := 0; i < 2; i++ {
+			MustResolve[*config](scope)
+			MustResolve[*session](scope)
+			MustResolve[*handler](scope)
+		}
+	}
+
+	expected := map[string]int{"singleton": 1, "request": 2, "transient": 4}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+	if MustResolve[*config](container) != MustResolve[*config](scopes[0]) {
+		t.Errorf("expected singletons to be shared between the container and scopes")
+	}
+	if MustResolve[*session](scopes[0]) == MustResolve[*session](scopes[1]) {
+		t.Errorf("expected request scoped services not to be shared between scopes")
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	builder := NewBuilder()
+	Register[*session](builder, Lifetime_Request, func() *session { return &session{} })
+	Register[*repository](builder, Lifetime_Singleton, func() (*repository, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	container, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		resolve func() error
+		err     string
+	}{
+		{
+			name: "not registered",
+			resolve: func() error {
+				_, err := Resolve[*handler](container)
+				return err
+			},
+			err: "unable to locate service *container.handler",
+		},
+		{
+			name: "request scoped outside of a scope",
+			resolve: func() error {
+				_, err := Resolve[*session](container)
+				return err
+			},
+			err: "request scoped service *container.session cannot be resolved outside of a scope",
+		},
+		{
+			name: "factory error",
+			resolve: func() error {
+				_, err := Resolve[*repository](container.NewScope())
+				return err
+			},
+			err: "unable to create *container.repository: connection refused",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.resolve()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestScopeDispose(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolve  func(scope *Scope)
+		expected []string
+	}{
+		{
+			name:     "nothing resolved",
+			resolve:  func(scope *Scope) {},
+			expected: []string{},
+		},
+		{
+			name: "reverse order of creation",
+			resolve: func(scope *Scope) {
+				MustResolve[*first](scope)
+				MustResolve[*second](scope)
+			},
+			expected: []string{"second", "first"},
+		},
+		{
+			name: "dependencies are disposed after their dependants",
+			resolve: func(scope *Scope) {
+				MustResolve[*third](scope)
+			},
+			expected: []string{"third", "second", "first"},
+		},
+		{
+			name: "request scoped services are disposed once",
+			resolve: func(scope *Scope) {
+				MustResolve[*second](scope)
+				MustResolve[*second](scope)
+			},
+			expected: []string{"second", "first"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			disposed := []string{}
+			builder := NewBuilder()
+			Register[*first](builder, Lifetime_Request, func() *first {
+				return &first{&disposable{name: "first", disposed: &disposed}}
+			})
+			Register[*second](builder, Lifetime_Request, func(*first) *second {
+				return &second{&disposable{name: "second", disposed: &disposed}}
+			})
+			Register[*third](builder, Lifetime_Transient, func(*second) *third {
+				return &third{&disposable{name: "third", disposed: &disposed}}
+			})
+
+			container, err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scope := container.NewScope()
+			test.resolve(scope)
+			scope.Dispose()
+			scope.Dispose()
+
+			if !reflect.DeepEqual(disposed, test.expected) {
+				t.Errorf("expected %v to be disposed, got %v", test.expected, disposed)
+			}
+		})
+	}
+}
+
+func TestScopeDisposeLeavesSingletonsAndInstances(t *testing.T) {
+	disposed := []string{}
+	instance := &second{&disposable{name: "instance", disposed: &disposed}}
+
+	builder := NewBuilder()
+	RegisterInstance(builder, instance)
+	Register[*first](builder, Lifetime_Singleton, func() *first {
+		return &first{&disposable{name: "singleton", disposed: &disposed}}
+	})
+
+	container, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := container.NewScope()
+	MustResolve[*first](scope)
+	MustResolve[*second](scope)
+	scope.Dispose()
+
+	if len(disposed) != 0 {
+		t.Fatalf("expected nothing to be disposed with the scope, got %v", disposed)
+	}
+
+	container.Dispose()
+	if !reflect.DeepEqual(disposed, []string{"singleton"}) {
+		t.Errorf("expected only the singleton to be disposed with the container, got %v", disposed)
+	}
+}
+
+func TestResolveAfterDisposeDisposesTheInstance(t *testing.T) {
+	disposed := []string{}
+	builder := NewBuilder()
+	Register[*first](builder, Lifetime_Request, func() *first {
+		return &first{&disposable{name: "first", disposed: &disposed}}
+	})
+
+	container, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := container.NewScope()
+	scope.Dispose()
+
+	_, err = Resolve[*first](scope)
+	if err == nil || !strings.Contains(err.Error(), "the scope has been disposed") {
+		t.Errorf("expected an error as the scope has been disposed, got %v", err)
+	}
+	if !reflect.DeepEqual(disposed, []string{"first"}) {
+		t.Errorf("expected the instance created after the scope was disposed to be disposed, got %v", disposed)
+	}
+}
@@ -0,0 +1,18 @@
+package container
+
+type Lifetime struct {
+	slug string
+}
+
+func (lifetime Lifetime) String() string {
+	return lifetime.slug
+}
+
+var (
+	// Lifetime_Singleton creates a single instance that is shared for the lifetime of the container
+	Lifetime_Singleton = Lifetime{slug: "Singleton"}
+	// Lifetime_Transient creates a new instance every time the service is resolved
+	Lifetime_Transient = Lifetime{slug: "Transient"}
+	// Lifetime_Request creates a single instance per scope, typically a HTTP request
+	Lifetime_Request = Lifetime{slug: "Request"}
+)
@@ -42,7 +42,7 @@ func (ep *eventPublisherExecutionService) Publish(event cloudevents.Event) bool
 		return false
 	}
 
-	ctx := cloudevents.ContextWithTarget(context.Background(), ep.config.GetStringValueOrDefault("ingestion.uri", "http://keas-ingestion.keas.svc.cluster.local/ingest"))
+	ctx := cloudevents.ContextWithTarget(log.WithCloudEventsLogger(context.Background()), ep.config.GetStringValueOrDefault("ingestion.uri", "http://keas-ingestion.keas.svc.cluster.local/ingest"))
 	if ep.client == nil {
 		sender, err := cloudevents.NewHTTP(cloudevents.WithHeader("Authorization", "ApiKey "+ep.config.GetStringValueOrDefault("ingestion.auth.token", "")))
 		if err != nil {
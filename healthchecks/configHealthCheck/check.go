@@ -43,9 +43,9 @@ func (healthCheck KubernetesConfigMapCheck) Check() healthchecks.HealthCheckResu
 	}
 
 	for _, provider := range healthCheck.config.Providers {
-		cp, ok := provider.(*configuration.KubernetesConfigMapConfigurationProvider)
-		if ok && cp.Name() == healthCheck.name {
-			if cp.Exists {
+		cp, ok := provider.(configuration.KubernetesResourceProvider)
+		if ok && cp.Type() == "KubernetesConfigMap" && cp.Name() == healthCheck.name {
+			if cp.ResourceExists() {
 				result.State = healthchecks.HealthCheckState_Healthy
 			}
 			return result
@@ -67,9 +67,9 @@ func (healthCheck KubernetesSecretCheck) Check() healthchecks.HealthCheckResult
 	}
 
 	for _, provider := range healthCheck.config.Providers {
-		cp, ok := provider.(*configuration.KubernetesSecretConfigurationProvider)
-		if ok && cp.Name() == healthCheck.name {
-			if cp.Exists {
+		cp, ok := provider.(configuration.KubernetesResourceProvider)
+		if ok && cp.Type() == "KubernetesSecret" && cp.Name() == healthCheck.name {
+			if cp.ResourceExists() {
 				result.State = healthchecks.HealthCheckState_Healthy
 			}
 			return result
@@ -0,0 +1,123 @@
+package configHealthCheck
+
+import (
+	"testing"
+
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/healthchecks"
+)
+
+type resourceProvider struct {
+	providerType string
+	name         string
+	exists       bool
+}
+
+func (provider resourceProvider) Name() string {
+	return provider.name
+}
+
+func (provider resourceProvider) Type() string {
+	return provider.providerType
+}
+
+func (provider resourceProvider) TryGetValue(key string) (bool, string) {
+	return false, ""
+}
+
+func (provider resourceProvider) ResourceExists() bool {
+	return provider.exists
+}
+
+type namedProvider struct {
+	providerType string
+	name         string
+}
+
+func (provider namedProvider) Name() string {
+	return provider.name
+}
+
+func (provider namedProvider) Type() string {
+	return provider.providerType
+}
+
+func (provider namedProvider) TryGetValue(key string) (bool, string) {
+	return false, ""
+}
+
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []configuration.ConfigurationProvider
+		configMap healthchecks.HealthCheckState
+		secret    healthchecks.HealthCheckState
+	}{
+		{
+			name:      "no providers",
+			configMap: healthchecks.HealthCheckState_Unhealthy,
+			secret:    healthchecks.HealthCheckState_Unhealthy,
+		},
+		{
+			name: "resources exist",
+			providers: []configuration.ConfigurationProvider{
+				resourceProvider{providerType: "KubernetesConfigMap", name: "settings", exists: true},
+				resourceProvider{providerType: "KubernetesSecret", name: "settings", exists: true},
+			},
+			configMap: healthchecks.HealthCheckState_Healthy,
+			secret:    healthchecks.HealthCheckState_Healthy,
+		},
+		{
+			name: "resources don't exist",
+			providers: []configuration.ConfigurationProvider{
+				resourceProvider{providerType: "KubernetesConfigMap", name: "settings"},
+				resourceProvider{providerType: "KubernetesSecret", name: "settings"},
+			},
+			configMap: healthchecks.HealthCheckState_Unhealthy,
+			secret:    healthchecks.HealthCheckState_Unhealthy,
+		},
+		{
+			name: "only the matching type is checked",
+			providers: []configuration.ConfigurationProvider{
+				resourceProvider{providerType: "KubernetesSecret", name: "settings", exists: true},
+			},
+			configMap: healthchecks.HealthCheckState_Unhealthy,
+			secret:    healthchecks.HealthCheckState_Healthy,
+		},
+		{
+			name: "different name",
+			providers: []configuration.ConfigurationProvider{
+				resourceProvider{providerType: "KubernetesConfigMap", name: "other", exists: true},
+				resourceProvider{providerType: "KubernetesSecret", name: "other", exists: true},
+			},
+			configMap: healthchecks.HealthCheckState_Unhealthy,
+			secret:    healthchecks.HealthCheckState_Unhealthy,
+		},
+		{
+			name: "providers that can't report whether the resource exists",
+			providers: []configuration.ConfigurationProvider{
+				namedProvider{providerType: "KubernetesConfigMap", name: "settings"},
+				namedProvider{providerType: "KubernetesSecret", name: "settings"},
+			},
+			configMap: healthchecks.HealthCheckState_Unhealthy,
+			secret:    healthchecks.HealthCheckState_Unhealthy,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := configuration.NewConfigurationBuilder(false)
+			for _, provider := range test.providers {
+				builder.AddConfigurationProvider(provider)
+			}
+			config := builder.Build()
+
+			if state := NewKubernetesConfigMapCheck("settings", config).Check().State; state != test.configMap {
+				t.Errorf("expected the ConfigMap check to be %s, got %s", test.configMap, state)
+			}
+			if state := NewKubernetesSecretCheckCheck("settings", config).Check().State; state != test.secret {
+				t.Errorf("expected the Secret check to be %s, got %s", test.secret, state)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package httpcache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type Entry struct {
+	Status  int
+	Headers map[string][]string
+	Body    []byte
+	ETag    string
+	Tags    []string
+	Expires time.Time
+}
+
+type cacheItem struct {
+	key   string
+	entry *Entry
+}
+
+// Cache is an in-memory LRU store of responses that are indexed by tag so that they can be invalidated when
+// the underlying data changes, ie: from a configuration change handler
+type Cache struct {
+	capacity int
+	entries  map[string]*list.Element
+	tags     map[string]map[string]bool
+	order    *list.List
+	mutex    *sync.Mutex
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		capacity: 1000,
+		entries:  map[string]*list.Element{},
+		tags:     map[string]map[string]bool{},
+		order:    list.New(),
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func (cache *Cache) Get(key string) (*Entry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, found := cache.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	item := element.Value.(*cacheItem)
+	if time.Now().After(item.entry.Expires) {
+		cache.remove(element)
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+	return item.entry, true
+}
+
+func (cache *Cache) Set(key string, entry *Entry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.entries[key]; found {
+		cache.remove(element)
+	}
+
+	cache.entries[key] = cache.order.PushFront(&cacheItem{key: key, entry: entry})
+	for _, tag := range entry.Tags {
+		if cache.tags[tag] == nil {
+			cache.tags[tag] = map[string]bool{}
+		}
+		cache.tags[tag][key] = true
+	}
+
+	cache.evict()
+}
+
+// InvalidateTags removes every response that was cached with any of the tags
+func (cache *Cache) InvalidateTags(tags ...string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for _, tag := range tags {
+		for key := range cache.tags[tag] {
+			if element, found := cache.entries[key]; found {
+				cache.remove(element)
+			}
+		}
+		delete(cache.tags, tag)
+	}
+}
+
+// Purge removes every cached response
+func (cache *Cache) Purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries = map[string]*list.Element{}
+	cache.tags = map[string]map[string]bool{}
+	cache.order.Init()
+}
+
+func (cache *Cache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.order.Len()
+}
+
+func (cache *Cache) setCapacity(capacity int) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.capacity = capacity
+	cache.evict()
+}
+
+func (cache *Cache) evict() {
+	for cache.capacity > 0 && cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back())
+	}
+}
+
+func (cache *Cache) remove(element *list.Element) {
+	item := element.Value.(*cacheItem)
+	cache.order.Remove(element)
+	delete(cache.entries, item.key)
+
+	for _, tag := range item.entry.Tags {
+		delete(cache.tags[tag], item.key)
+		if len(cache.tags[tag]) == 0 {
+			delete(cache.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/authentication"
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+const (
+	HeaderCache string = "X-Cache"
+
+	tagsKey string = "keas.cache.tags"
+)
+
+// headers that are specific to the original exchange and must not be served from the cache
+var excludedHeaders = map[string]bool{
+	fiber.HeaderContentLength: true,
+	fiber.HeaderDate:          true,
+	fiber.HeaderConnection:    true,
+	fiber.HeaderServer:        true,
+	fiber.HeaderXRequestID:    true,
+}
+
+type Options struct {
+	// Next skips the middleware for the request when it returns true
+	Next func(c *fiber.Ctx) bool
+	// Cache defaults to a new in-memory cache, provide one to invalidate responses by tag
+	Cache *Cache
+	// Logger writes the warnings for invalid rules, defaults to the global logger
+	Logger *zap.Logger
+}
+
+type settings struct {
+	enabled bool
+	etags   bool
+	rules   []Rule
+	vary    []string
+}
+
+// Tag adds tags to the cached response in addition to the tags of the matching rule
+func Tag(c *fiber.Ctx, tags ...string) {
+	existing, _ := c.Locals(tagsKey).([]string)
+	c.Locals(tagsKey, append(existing, tags...))
+}
+
+// New computes strong ETags for GET responses, answering If-None-Match with 304, and caches the responses
+// of routes matching the rules in server.cache.rules. The configuration is reloaded on change
+func New(config *configuration.ConfigurationRoot, options Options) fiber.Handler {
+	cache := options.Cache
+	if cache == nil {
+		cache = NewCache()
+	}
+	logger := options.Logger
+	if logger == nil {
+		logger = log.Logger
+	}
+
+	current := atomic.Value{}
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		cache.setCapacity(c.GetIntValueOrDefault("server.cache.maxEntries", 1000))
+		current.Store(settings{
+			enabled: c.GetBooleanValueOrDefault("server.cache.enabled", true),
+			etags:   c.GetBooleanValueOrDefault("server.cache.etag", true),
+			rules:   parseRules(c.GetStringSliceValueOrDefault("server.cache.rules", []string{}), logger),
+			vary:    c.GetStringSliceValueOrDefault("server.cache.vary", []string{fiber.HeaderAccept}),
+		})
+	})
+
+	return func(c *fiber.Ctx) error {
+		if options.Next != nil && options.Next(c) {
+			return c.Next()
+		}
+
+		method := c.Method()
+		if method != fiber.MethodGet && method != fiber.MethodHead {
+			return c.Next()
+		}
+
+		settings := current.Load().(settings)
+		rule, cacheable := findRule(settings.rules, c.Path())
+		cacheable = cacheable && settings.enabled
+
+		key := ""
+		if cacheable {
+			key = cacheKey(c, settings.vary)
+			if entry, found := cache.Get(key); found {
+				return serve(c, entry)
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		response := c.Response()
+		if response.StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		etag := string(response.Header.Peek(fiber.HeaderETag))
+		if etag == "" && (settings.etags || cacheable) {
+			etag = computeETag(response.Body())
+			c.Set(fiber.HeaderETag, etag)
+		}
+
+		if cacheable && method == fiber.MethodGet && isStorable(c) {
+			tags, _ := c.Locals(tagsKey).([]string)
+			cache.Set(key, capture(c, etag, append(append([]string{}, rule.Tags...), tags...), rule.TTL))
+			c.Set(HeaderCache, "MISS")
+		}
+
+		if etag != "" && notModified(c, etag) {
+			c.Status(fiber.StatusNotModified)
+			response.ResetBody()
+		}
+
+		return nil
+	}
+}
+
+// cacheKey scopes the entry to the caller so that authenticated responses are never served to another principal
+func cacheKey(c *fiber.Ctx, vary []string) string {
+	key := strings.Builder{}
+	if principal, found := authentication.GetPrincipal(c); found {
+		key.WriteString(principal.Type + ":" + principal.Id)
+	}
+	key.WriteString("|" + c.OriginalURL())
+	for _, header := range vary {
+		key.WriteString("|" + c.Get(header))
+	}
+	return key.String()
+}
+
+func computeETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// notModified uses the weak comparison required for If-None-Match
+func notModified(c *fiber.Ctx, etag string) bool {
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isStorable(c *fiber.Ctx) bool {
+	if len(c.Response().Header.Peek(fiber.HeaderSetCookie)) > 0 {
+		return false
+	}
+
+	cacheControl := strings.ToLower(string(c.Response().Header.Peek(fiber.HeaderCacheControl)))
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private")
+}
+
+func capture(c *fiber.Ctx, etag string, tags []string, ttl time.Duration) *Entry {
+	entry := &Entry{
+		Status:  c.Response().StatusCode(),
+		Headers: map[string][]string{},
+		Body:    append([]byte(nil), c.Response().Body()...),
+		ETag:    etag,
+		Tags:    tags,
+		Expires: time.Now().Add(ttl),
+	}
+
+	c.Response().Header.VisitAll(func(key []byte, value []byte) {
+		name := string(key)
+		if !excludedHeaders[name] {
+			entry.Headers[name] = append(entry.Headers[name], string(value))
+		}
+	})
+
+	return entry
+}
+
+func serve(c *fiber.Ctx, entry *Entry) error {
+	for name, values := range entry.Headers {
+		c.Response().Header.Del(name)
+		for _, value := range values {
+			c.Response().Header.Add(name, value)
+		}
+	}
+	c.Set(HeaderCache, "HIT")
+
+	if notModified(c, entry.ETag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.Status(entry.Status).Send(entry.Body)
+}
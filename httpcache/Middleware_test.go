@@ -0,0 +1,213 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/authentication"
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap"
+)
+
+type response struct {
+	status int
+	cache  string
+	etag   string
+	body   string
+}
+
+func newCachedApp(t *testing.T, values map[string]string) (*fiber.App, *Cache, *int) {
+	settings := map[string]string{
+		"server.cache.rules": "/products/*=1m#products",
+		"auth.apiKeys":       "alice:key-a,bob:key-b",
+	}
+	for key, value := range values {
+		settings[key] = value
+	}
+
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(configuration.NewInMemoryConfigurationProvider("test", settings))
+	config := builder.Build()
+
+	calls := 0
+	cache := NewCache()
+	app := fiber.New()
+	app.Use(authentication.New(config, authentication.Options{AllowAnonymous: true, DisableJwt: true, Logger: zap.NewNop()}))
+	app.Use(New(config, Options{Cache: cache, Logger: zap.NewNop()}))
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		calls++
+		Tag(c, "product-"+c.Params("id"))
+		return c.SendString(fmt.Sprintf("product %s #%d", c.Params("id"), calls))
+	})
+	app.Get("/orders/:id", func(c *fiber.Ctx) error {
+		calls++
+		return c.SendString("order " + c.Params("id"))
+	})
+	return app, cache, &calls
+}
+
+func send(t *testing.T, app *fiber.App, path string, headers map[string]string) response {
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+
+	return response{
+		status: res.StatusCode,
+		cache:  res.Header.Get(HeaderCache),
+		etag:   res.Header.Get(fiber.HeaderETag),
+		body:   string(body),
+	}
+}
+
+func TestCacheKeyIsScopedToThePrincipalAndVary(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  map[string]string
+		second map[string]string
+		shared bool
+	}{
+		{
+			name:   "same principal",
+			first:  map[string]string{"X-API-Key": "key-a"},
+			second: map[string]string{"X-API-Key": "key-a"},
+			shared: true,
+		},
+		{
+			name:   "different principals",
+			first:  map[string]string{"X-API-Key": "key-a"},
+			second: map[string]string{"X-API-Key": "key-b"},
+		},
+		{
+			name:   "anonymous and authenticated",
+			first:  map[string]string{},
+			second: map[string]string{"X-API-Key": "key-a"},
+		},
+		{
+			name:   "different accept",
+			first:  map[string]string{fiber.HeaderAccept: "application/json"},
+			second: map[string]string{fiber.HeaderAccept: "application/xml"},
+		},
+		{
+			name:   "accept-encoding is not varied by default",
+			first:  map[string]string{fiber.HeaderAcceptEncoding: "gzip"},
+			second: map[string]string{fiber.HeaderAcceptEncoding: "br"},
+			shared: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, _, calls := newCachedApp(t, nil)
+
+			first := send(t, app, "/products/1", test.first)
+			second := send(t, app, "/products/1", test.second)
+
+			if first.cache != "MISS" {
+				t.Errorf("expected the first response to be a miss, got %q", first.cache)
+			}
+			if test.shared != (second.cache == "HIT") || test.shared != (*calls == 1) {
+				t.Errorf("expected the response to be shared (%t), got %q after %d calls", test.shared, second.cache, *calls)
+			}
+			if test.shared && second.body != first.body {
+				t.Errorf("expected the cached body %q, got %q", first.body, second.body)
+			}
+		})
+	}
+}
+
+func TestCacheKeyVariesOnConfiguredHeaders(t *testing.T) {
+	app, _, calls := newCachedApp(t, map[string]string{"server.cache.vary": "Accept-Language"})
+
+	send(t, app, "/products/1", map[string]string{fiber.HeaderAcceptLanguage: "en"})
+	send(t, app, "/products/1", map[string]string{fiber.HeaderAcceptLanguage: "fr"})
+	send(t, app, "/products/1", map[string]string{fiber.HeaderAcceptLanguage: "en"})
+
+	if *calls != 2 {
+		t.Errorf("expected a response per language, got %d calls", *calls)
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	app, _, _ := newCachedApp(t, nil)
+
+	first := send(t, app, "/products/1", nil)
+	if first.status != fiber.StatusOK || first.etag == "" {
+		t.Fatalf("expected a response with an etag, got %+v", first)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "cached match", path: "/products/1", ifNoneMatch: first.etag, status: fiber.StatusNotModified},
+		{name: "cached weak match", path: "/products/1", ifNoneMatch: `"other", W/` + first.etag, status: fiber.StatusNotModified},
+		{name: "cached wildcard", path: "/products/1", ifNoneMatch: "*", status: fiber.StatusNotModified},
+		{name: "cached mismatch", path: "/products/1", ifNoneMatch: `"other"`, status: fiber.StatusOK},
+		{name: "uncached match", path: "/orders/1", ifNoneMatch: computeETag([]byte("order 1")), status: fiber.StatusNotModified},
+		{name: "uncached mismatch", path: "/orders/1", ifNoneMatch: `"other"`, status: fiber.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := send(t, app, test.path, map[string]string{fiber.HeaderIfNoneMatch: test.ifNoneMatch})
+			if res.status != test.status {
+				t.Errorf("expected status %d, got %d", test.status, res.status)
+			}
+			if test.status == fiber.StatusNotModified && res.body != "" {
+				t.Errorf("expected no body with a 304, got %q", res.body)
+			}
+		})
+	}
+}
+
+func TestInvalidateTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		invalidated []string
+	}{
+		{name: "rule tag", tags: []string{"products"}, invalidated: []string{"/products/1", "/products/2"}},
+		{name: "request tag", tags: []string{"product-1"}, invalidated: []string{"/products/1"}},
+		{name: "unknown tag", tags: []string{"orders"}, invalidated: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, cache, _ := newCachedApp(t, nil)
+
+			initial := map[string]string{}
+			for _, path := range []string{"/products/1", "/products/2"} {
+				initial[path] = send(t, app, path, nil).body
+			}
+
+			cache.InvalidateTags(test.tags...)
+			if cache.Len() != 2-len(test.invalidated) {
+				t.Errorf("expected %d entries to remain, got %d", 2-len(test.invalidated), cache.Len())
+			}
+
+			for path, body := range initial {
+				invalidated := false
+				for _, p := range test.invalidated {
+					invalidated = invalidated || p == path
+				}
+
+				res := send(t, app, path, nil)
+				if invalidated != (res.cache == "MISS") || invalidated == (res.body == body) {
+					t.Errorf("expected %s to be invalidated (%t), got %+v", path, invalidated, res)
+				}
+			}
+		})
+	}
+}
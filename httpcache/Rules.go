@@ -0,0 +1,66 @@
+package httpcache
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Rule caches GET responses for paths matching the pattern, patterns ending in * match the prefix and all
+// other patterns use path.Match
+type Rule struct {
+	Pattern string
+	TTL     time.Duration
+	Tags    []string
+}
+
+// parseRules parses rules in the format pattern=ttl#tag1|tag2, ie: /products/*=5m#products,/settings=1h
+func parseRules(values []string, logger *zap.Logger) []Rule {
+	rules := []Rule{}
+	for _, value := range values {
+		definition, tags, _ := strings.Cut(strings.TrimSpace(value), "#")
+		pattern, ttl, found := strings.Cut(definition, "=")
+		if !found {
+			logger.Warn("Ignoring cache rule without a ttl", zap.String("rule", value))
+			continue
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(ttl))
+		if err != nil || duration <= 0 {
+			logger.Warn("Ignoring cache rule with an invalid ttl", zap.String("rule", value))
+			continue
+		}
+
+		rule := Rule{
+			Pattern: strings.TrimSpace(pattern),
+			TTL:     duration,
+		}
+		for _, tag := range strings.Split(tags, "|") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				rule.Tags = append(rule.Tags, tag)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (rule Rule) matches(requestPath string) bool {
+	if strings.HasSuffix(rule.Pattern, "*") {
+		return strings.HasPrefix(requestPath, strings.TrimSuffix(rule.Pattern, "*"))
+	}
+
+	matched, _ := path.Match(rule.Pattern, requestPath)
+	return matched
+}
+
+func findRule(rules []Rule, requestPath string) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.matches(requestPath) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,86 @@
+package httpcache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected []Rule
+		warnings int
+	}{
+		{
+			name:     "prefix with tags",
+			values:   []string{" /products/* = 5m # products | catalogue "},
+			expected: []Rule{{Pattern: "/products/*", TTL: 5 * time.Minute, Tags: []string{"products", "catalogue"}}},
+		},
+		{
+			name:     "without tags",
+			values:   []string{"/settings=1h"},
+			expected: []Rule{{Pattern: "/settings", TTL: time.Hour}},
+		},
+		{
+			name:     "empty tags are ignored",
+			values:   []string{"/settings=1h#||"},
+			expected: []Rule{{Pattern: "/settings", TTL: time.Hour}},
+		},
+		{
+			name:     "without a ttl",
+			values:   []string{"/products/*", "/settings=1h"},
+			expected: []Rule{{Pattern: "/settings", TTL: time.Hour}},
+			warnings: 1,
+		},
+		{
+			name:     "invalid ttl",
+			values:   []string{"/products/*=soon", "/orders=0s", "/users=-1m"},
+			expected: []Rule{},
+			warnings: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+
+			rules := parseRules(test.values, zap.New(core))
+			if !reflect.DeepEqual(rules, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, rules)
+			}
+			if logs.Len() != test.warnings {
+				t.Errorf("expected %d warnings, got %d", test.warnings, logs.Len())
+			}
+		})
+	}
+}
+
+func TestFindRule(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "/products/*", TTL: time.Minute},
+		{Pattern: "/orders/?", TTL: time.Hour},
+	}
+
+	tests := map[string]time.Duration{
+		"/products/":   time.Minute,
+		"/products/1":  time.Minute,
+		"/products/1/": time.Minute,
+		"/orders/1":    time.Hour,
+		"/orders/12":   0,
+		"/products":    0,
+		"/":            0,
+	}
+
+	for path, ttl := range tests {
+		rule, found := findRule(rules, path)
+		if found != (ttl > 0) || rule.TTL != ttl {
+			t.Errorf("expected %s to match the rule with ttl %s, got %+v (%t)", path, ttl, rule, found)
+		}
+	}
+}
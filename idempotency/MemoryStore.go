@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key      string
+	response *Response
+	expires  time.Time
+}
+
+// MemoryStore is an in-memory LRU store, responses are only replayed for duplicates routed to the same replica
+type MemoryStore struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	locks    map[string]time.Time
+	mutex    *sync.Mutex
+}
+
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+		locks:    map[string]time.Time{},
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func (store *MemoryStore) Get(ctx context.Context, key string) (*Response, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	element, found := store.entries[key]
+	if !found {
+		return nil, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		store.remove(element)
+		return nil, nil
+	}
+
+	store.order.MoveToFront(element)
+	return entry.response, nil
+}
+
+func (store *MemoryStore) Set(ctx context.Context, key string, response *Response, ttl time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if element, found := store.entries[key]; found {
+		store.remove(element)
+	}
+
+	store.entries[key] = store.order.PushFront(&memoryEntry{
+		key:      key,
+		response: response,
+		expires:  time.Now().Add(ttl),
+	})
+
+	for store.capacity > 0 && store.order.Len() > store.capacity {
+		store.remove(store.order.Back())
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	if expires, found := store.locks[key]; found && now.Before(expires) {
+		return false, nil
+	}
+
+	store.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (store *MemoryStore) Unlock(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.locks, key)
+	return nil
+}
+
+func (store *MemoryStore) remove(element *list.Element) {
+	store.order.Remove(element)
+	delete(store.entries, element.Value.(*memoryEntry).key)
+}
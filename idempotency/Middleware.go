@@ -0,0 +1,175 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/authentication"
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+const (
+	HeaderReplayed string = "Idempotent-Replayed"
+
+	Code_KeyReused string = "idempotency_key_reused"
+)
+
+// headers that are specific to the original exchange and must not be replayed
+var excludedHeaders = map[string]bool{
+	fiber.HeaderContentLength: true,
+	fiber.HeaderDate:          true,
+	fiber.HeaderConnection:    true,
+	fiber.HeaderServer:        true,
+	fiber.HeaderXRequestID:    true,
+}
+
+type Options struct {
+	// Next skips the middleware for the request when it returns true
+	Next func(c *fiber.Ctx) bool
+	// Store defaults to an in-memory LRU store with server.idempotency.capacity entries
+	Store Store
+}
+
+type settings struct {
+	header      string
+	methods     map[string]bool
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+// New replays the first response for requests that share an Idempotency-Key header using the
+// server.idempotency.* configuration keys. Responses with a 5xx status are not stored so that they can be retried
+func New(config *configuration.ConfigurationRoot, options Options) fiber.Handler {
+	if options.Store == nil {
+		options.Store = NewMemoryStore(config.GetIntValueOrDefault("server.idempotency.capacity", 10000))
+	}
+
+	current := atomic.Value{}
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		methods := map[string]bool{}
+		for _, method := range c.GetStringSliceValueOrDefault("server.idempotency.methods", []string{fiber.MethodPost, fiber.MethodPatch}) {
+			methods[strings.ToUpper(strings.TrimSpace(method))] = true
+		}
+
+		current.Store(settings{
+			header:      c.GetStringValueOrDefault("server.idempotency.header", "Idempotency-Key"),
+			methods:     methods,
+			ttl:         c.GetDurationValueOrDefault("server.idempotency.ttl", 24*time.Hour),
+			lockTimeout: c.GetDurationValueOrDefault("server.idempotency.lockTimeout", time.Minute),
+		})
+	})
+
+	return func(c *fiber.Ctx) error {
+		if options.Next != nil && options.Next(c) {
+			return c.Next()
+		}
+
+		settings := current.Load().(settings)
+		key := c.Get(settings.header)
+		if key == "" || !settings.methods[c.Method()] {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return problems.NewValidationError("the idempotency key must be 255 characters or less").
+				WithFieldError(settings.header, "must be at most 255 characters long", "max")
+		}
+
+		ctx := c.UserContext()
+		storeKey := scopedKey(c, key)
+		fingerprint := fingerprint(c)
+
+		response, err := options.Store.Get(ctx, storeKey)
+		if err != nil {
+			return err
+		}
+		if response != nil {
+			return replay(c, response, fingerprint)
+		}
+
+		locked, err := options.Store.Lock(ctx, storeKey, settings.lockTimeout)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return problems.NewConflictError("a request with the same idempotency key is already being processed")
+		}
+		defer options.Store.Unlock(ctx, storeKey)
+
+		// the original request may have completed between reading the store and acquiring the lock
+		response, err = options.Store.Get(ctx, storeKey)
+		if err != nil {
+			return err
+		}
+		if response != nil {
+			return replay(c, response, fingerprint)
+		}
+
+		// errors are rendered here rather than by the app so that the rendered response can be stored
+		if err := c.Next(); err != nil {
+			problems.SetHandledError(c, err)
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		if c.Response().StatusCode() >= http.StatusInternalServerError {
+			return nil
+		}
+
+		return options.Store.Set(ctx, storeKey, capture(c, fingerprint), settings.ttl)
+	}
+}
+
+// scopedKey scopes the key to the caller and route so that keys chosen by different clients can't collide
+func scopedKey(c *fiber.Ctx, key string) string {
+	principal := ""
+	if p, found := authentication.GetPrincipal(c); found {
+		principal = p.Type + ":" + p.Id
+	}
+	return principal + "|" + c.Method() + " " + c.Path() + "|" + key
+}
+
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.Sum256(c.Body())
+	return hex.EncodeToString(hash[:])
+}
+
+func capture(c *fiber.Ctx, fingerprint string) *Response {
+	response := &Response{
+		Status:      c.Response().StatusCode(),
+		Headers:     map[string][]string{},
+		Body:        append([]byte(nil), c.Response().Body()...),
+		Fingerprint: fingerprint,
+	}
+
+	c.Response().Header.VisitAll(func(key []byte, value []byte) {
+		name := string(key)
+		if !excludedHeaders[name] {
+			response.Headers[name] = append(response.Headers[name], string(value))
+		}
+	})
+
+	return response
+}
+
+func replay(c *fiber.Ctx, response *Response, fingerprint string) error {
+	if response.Fingerprint != fingerprint {
+		return problems.New(http.StatusUnprocessableEntity, Code_KeyReused, "the idempotency key has already been used for a different request")
+	}
+
+	for name, values := range response.Headers {
+		c.Response().Header.Del(name)
+		for _, value := range values {
+			c.Response().Header.Add(name, value)
+		}
+	}
+	c.Set(HeaderReplayed, "true")
+
+	return c.Status(response.Status).Send(response.Body)
+}
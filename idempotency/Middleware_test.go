@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+func newTestApp(calls *int) *fiber.App {
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(configuration.NewInMemoryConfigurationProvider("test", map[string]string{}))
+	config := builder.Build()
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			problem := problems.From(err)
+			return c.Status(problem.Status).JSON(problem)
+		},
+	})
+	app.Use(compress.New())
+	app.Use(New(config, Options{}))
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		*calls++
+		return c.Status(fiber.StatusCreated).SendString(strings.Repeat("order ", 200))
+	})
+	return app
+}
+
+func newRequest(body string, acceptEncoding string) *http.Request {
+	req := httptest.NewRequest(fiber.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", "key-1")
+	if acceptEncoding != "" {
+		req.Header.Set(fiber.HeaderAcceptEncoding, acceptEncoding)
+	}
+	return req
+}
+
+func TestReplaysTheFirstResponse(t *testing.T) {
+	calls := 0
+	app := newTestApp(&calls)
+
+	first, err := app.Test(newRequest(`{"id":1}`, "gzip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.StatusCode != fiber.StatusCreated || first.Header.Get(fiber.HeaderContentEncoding) != "gzip" {
+		t.Fatalf("expected a compressed 201 response, got %d %q", first.StatusCode, first.Header.Get(fiber.HeaderContentEncoding))
+	}
+
+	// the replay must not depend on the encoding accepted by the first request
+	second, err := app.Test(newRequest(`{"id":1}`, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(second.Body)
+
+	if calls != 1 {
+		t.Errorf("expected the handler to be called once, got %d", calls)
+	}
+	if second.StatusCode != fiber.StatusCreated || second.Header.Get(HeaderReplayed) != "true" {
+		t.Errorf("expected a replayed 201 response, got %d replayed=%q", second.StatusCode, second.Header.Get(HeaderReplayed))
+	}
+	if encoding := second.Header.Get(fiber.HeaderContentEncoding); encoding != "" {
+		t.Errorf("expected an uncompressed replay, got %q", encoding)
+	}
+	if string(body) != strings.Repeat("order ", 200) {
+		t.Errorf("expected the original body to be replayed, got %q", body)
+	}
+}
+
+func TestRejectsKeysReusedWithADifferentBody(t *testing.T) {
+	calls := 0
+	app := newTestApp(&calls)
+
+	if _, err := app.Test(newRequest(`{"id":1}`, "")); err != nil {
+		t.Fatal(err)
+	}
+	response, err := app.Test(newRequest(`{"id":2}`, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Errorf("expected 422 when the key is reused for a different request, got %d", response.StatusCode)
+	}
+	if calls != 1 {
+		t.Errorf("expected the handler to be called once, got %d", calls)
+	}
+}
@@ -0,0 +1,27 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Response is the first response sent for an idempotency key, it's replayed for any duplicate requests
+type Response struct {
+	Status      int                 `json:"status"`
+	Headers     map[string][]string `json:"headers"`
+	Body        []byte              `json:"body"`
+	Fingerprint string              `json:"fingerprint"`
+}
+
+// Store persists responses and in-flight locks, implementations shared between replicas (ie: Redis) must
+// make Lock atomic
+type Store interface {
+	// Get returns nil when no response has been stored for the key
+	Get(ctx context.Context, key string) (*Response, error)
+	Set(ctx context.Context, key string, response *Response, ttl time.Duration) error
+
+	// Lock reserves the key whilst the request is processed, returning false when it's already reserved. The
+	// ttl releases the lock should the replica fail before calling Unlock
+	Lock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key string) error
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// bootstrapCapacity bounds the number of entries held before the logger is initialized, later entries are dropped
+const bootstrapCapacity = 1000
+
+type bufferedEntry struct {
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// bootstrapState is shared by every core derived from the bootstrap logger so that loggers captured
+// before initialization forward to the initialized logger afterwards
+type bootstrapState struct {
+	mutex   *sync.RWMutex
+	target  zapcore.Core
+	entries []bufferedEntry
+	dropped int
+}
+
+// bootstrapCore buffers entries until the logger is initialized, the buffered entries are then replayed
+// through the initialized logger so that its level, encoding and redaction rules apply
+type bootstrapCore struct {
+	state  *bootstrapState
+	fields []zapcore.Field
+}
+
+var bootstrap = &bootstrapState{
+	mutex: &sync.RWMutex{},
+}
+
+func init() {
+	Logger = newBootstrapLogger()
+}
+
+func newBootstrapLogger() *zap.Logger {
+	return zap.New(&bootstrapCore{state: bootstrap}, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+}
+
+func (core *bootstrapCore) Enabled(l zapcore.Level) bool {
+	core.state.mutex.RLock()
+	target := core.state.target
+	core.state.mutex.RUnlock()
+
+	if target != nil {
+		return target.Enabled(l)
+	}
+	return true
+}
+
+func (core *bootstrapCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(core.fields)+len(fields))
+	combined = append(combined, core.fields...)
+	combined = append(combined, fields...)
+	return &bootstrapCore{state: core.state, fields: combined}
+}
+
+func (core *bootstrapCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *bootstrapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	core.state.mutex.Lock()
+	target := core.state.target
+	if target == nil {
+		if len(core.state.entries) < bootstrapCapacity {
+			combined := make([]zapcore.Field, 0, len(core.fields)+len(fields))
+			combined = append(combined, core.fields...)
+			combined = append(combined, fields...)
+			core.state.entries = append(core.state.entries, bufferedEntry{entry: entry, fields: combined})
+		} else {
+			core.state.dropped++
+		}
+	}
+	core.state.mutex.Unlock()
+
+	if target != nil {
+		return write(target.With(core.fields), entry, fields)
+	}
+	return nil
+}
+
+// Sync writes the buffered entries to stderr when the logger was never initialized, ie: the process is
+// exiting because the configuration could not be loaded
+func (core *bootstrapCore) Sync() error {
+	core.state.mutex.RLock()
+	target := core.state.target
+	core.state.mutex.RUnlock()
+
+	if target != nil {
+		return target.Sync()
+	}
+
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	core.state.flush(zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), zapcore.DebugLevel), false)
+	return nil
+}
+
+// flush replays the buffered entries through the target, forward routes any subsequent entries to it
+func (state *bootstrapState) flush(target zapcore.Core, forward bool) {
+	state.mutex.Lock()
+	entries := state.entries
+	dropped := state.dropped
+	state.entries = nil
+	state.dropped = 0
+	if forward {
+		state.target = target
+	}
+	state.mutex.Unlock()
+
+	for _, buffered := range entries {
+		write(target, buffered.entry, buffered.fields)
+	}
+
+	if dropped > 0 {
+		write(target, zapcore.Entry{
+			Level:   zapcore.WarnLevel,
+			Time:    time.Now(),
+			Message: "Log entries were dropped before the logger was initialized",
+		}, []zapcore.Field{zap.Int("dropped", dropped)})
+	}
+}
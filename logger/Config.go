@@ -1,9 +1,38 @@
 package logger
 
+import "time"
+
 type Config struct {
 	AppName       string
 	LogLevel      string
 	IsDevelopment bool
 	Version       string
 	CommitSha     string
+
+	// PodName, Namespace and NodeName identify the pod the logs were written by
+	PodName   string
+	Namespace string
+	NodeName  string
+
+	// Encoding is json (default), console or logfmt
+	Encoding string
+	// Schema names the fields are written with, one of default, gcp, ecs or otel
+	Schema string
+	// Outputs are stdout (default), stderr or file paths which are rotated using File
+	Outputs []string
+	File    FileConfig
+
+	Sampling SamplingConfig
+	// DedupeWindow collapses identical errors logged within the window into a summary, disabled when zero
+	DedupeWindow time.Duration
+
+	// GcpProjectId prefixes trace ids with projects/<id>/traces/ when using the gcp schema
+	GcpProjectId string
+}
+
+type FileConfig struct {
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
 }
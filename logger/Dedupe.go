@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// dedupeCore collapses identical errors logged within the window, the first entry is written immediately and
+// the number of suppressed duplicates is written as a summary once the window has elapsed
+type dedupeCore struct {
+	zapcore.Core
+	state *dedupeState
+	// context holds the fields added using With so that they're part of the key
+	context []zapcore.Field
+}
+
+type dedupeState struct {
+	window  time.Duration
+	entries map[string]*dedupeEntry
+	mutex   *sync.Mutex
+	once    *sync.Once
+}
+
+type dedupeEntry struct {
+	core       zapcore.Core
+	entry      zapcore.Entry
+	fields     []zapcore.Field
+	first      time.Time
+	suppressed int
+}
+
+func newDedupeCore(core zapcore.Core, window time.Duration) zapcore.Core {
+	if window <= 0 {
+		return core
+	}
+
+	return &dedupeCore{
+		Core: core,
+		state: &dedupeState{
+			window:  window,
+			entries: map[string]*dedupeEntry{},
+			mutex:   &sync.Mutex{},
+			once:    &sync.Once{},
+		},
+	}
+}
+
+func (core *dedupeCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(core.context)+len(fields))
+	context = append(context, core.context...)
+	context = append(context, fields...)
+	return &dedupeCore{Core: core.Core.With(fields), state: core.state, context: context}
+}
+
+func (core *dedupeCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < zapcore.ErrorLevel {
+		return core.Core.Check(entry, checked)
+	}
+	if !core.Enabled(entry.Level) {
+		return checked
+	}
+	return checked.AddCore(entry, core)
+}
+
+func (core *dedupeCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	key := dedupeKey(entry, core.context, fields)
+	now := time.Now()
+
+	core.state.mutex.Lock()
+	existing, found := core.state.entries[key]
+	if found && now.Sub(existing.first) < core.state.window {
+		existing.suppressed++
+		core.state.mutex.Unlock()
+		return nil
+	}
+
+	core.state.entries[key] = &dedupeEntry{
+		core:   core.Core,
+		entry:  entry,
+		fields: fields,
+		first:  now,
+	}
+	core.state.mutex.Unlock()
+
+	core.state.once.Do(func() {
+		go core.state.run()
+	})
+
+	if found {
+		existing.writeSummary(core.state.window)
+	}
+	return write(core.Core, entry, fields)
+}
+
+// write checks the entry against the wrapped core rather than calling Write so that cores combined with
+// zapcore.NewTee only receive the levels they are enabled for
+func write(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field) error {
+	if checked := core.Check(entry, nil); checked != nil {
+		checked.Write(fields...)
+	}
+	return nil
+}
+
+func (core *dedupeCore) Sync() error {
+	core.state.flush(true)
+	return core.Core.Sync()
+}
+
+func (state *dedupeState) run() {
+	ticker := time.NewTicker(state.window)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		state.flush(false)
+	}
+}
+
+// flush writes the summaries of the windows that have elapsed, or of every window when forced
+func (state *dedupeState) flush(force bool) {
+	now := time.Now()
+	expired := []*dedupeEntry{}
+
+	state.mutex.Lock()
+	for key, entry := range state.entries {
+		if force || now.Sub(entry.first) >= state.window {
+			expired = append(expired, entry)
+			delete(state.entries, key)
+		}
+	}
+	state.mutex.Unlock()
+
+	for _, entry := range expired {
+		entry.writeSummary(state.window)
+	}
+}
+
+func (entry *dedupeEntry) writeSummary(window time.Duration) {
+	if entry.suppressed == 0 {
+		return
+	}
+
+	summary := entry.entry
+	summary.Time = time.Now()
+	summary.Stack = ""
+	write(entry.core, summary, append(entry.fields[:len(entry.fields):len(entry.fields)],
+		zap.Int("repeated", entry.suppressed),
+		zap.Duration("window", window),
+	))
+}
+
+// dedupeKey identifies an entry by its level, logger, message and the values of its fields so that only
+// identical entries are collapsed
+func dedupeKey(entry zapcore.Entry, context []zapcore.Field, fields []zapcore.Field) string {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range context {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	// maps are printed with sorted keys so the key is stable
+	return fmt.Sprintf("%s|%s|%s|%v", entry.Level.String(), entry.LoggerName, entry.Message, encoder.Fields)
+}
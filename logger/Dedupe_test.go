@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDedupeKeyIncludesFields(t *testing.T) {
+	entry := zapcore.Entry{Level: zapcore.ErrorLevel, LoggerName: "http", Message: "Server error"}
+
+	first := dedupeKey(entry, []zapcore.Field{zap.String("path", "/a")}, nil)
+	second := dedupeKey(entry, []zapcore.Field{zap.String("path", "/b")}, nil)
+	if first == second {
+		t.Errorf("expected entries with different context fields to have different keys, got %q", first)
+	}
+
+	first = dedupeKey(entry, nil, []zapcore.Field{zap.Error(errors.New("a"))})
+	second = dedupeKey(entry, nil, []zapcore.Field{zap.Error(errors.New("b"))})
+	if first == second {
+		t.Errorf("expected entries with different errors to have different keys, got %q", first)
+	}
+
+	first = dedupeKey(entry, []zapcore.Field{zap.String("path", "/a")}, []zapcore.Field{zap.Int("status", 500)})
+	second = dedupeKey(entry, []zapcore.Field{zap.String("path", "/a")}, []zapcore.Field{zap.Int("status", 500)})
+	if first != second {
+		t.Errorf("expected identical entries to have the same key, got %q and %q", first, second)
+	}
+}
+
+func TestDedupeCoreCollapsesIdenticalErrors(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newDedupeCore(observed, time.Hour))
+
+	for i := 0; i < 3; i++ {
+		logger.With(zap.String("path", "/a")).Error("Server error")
+	}
+	logger.With(zap.String("path", "/b")).Error("Server error")
+	logger.Info("not deduplicated")
+	logger.Info("not deduplicated")
+
+	if count := logs.FilterMessage("Server error").Len(); count != 2 {
+		t.Fatalf("expected 2 errors to be written before the window elapsed, got %d", count)
+	}
+	if count := logs.FilterMessage("not deduplicated").Len(); count != 2 {
+		t.Fatalf("expected entries below the error level to be written, got %d", count)
+	}
+
+	logger.Sync()
+	summaries := logs.FilterField(zap.Int("repeated", 2)).All()
+	if len(summaries) != 1 {
+		t.Fatalf("expected a summary of the suppressed errors, got %d", len(summaries))
+	}
+	if path := summaries[0].ContextMap()["path"]; path != "/a" {
+		t.Errorf("expected the summary to keep the context of the entry, got %v", path)
+	}
+}
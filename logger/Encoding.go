@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	Encoding_Json    string = "json"
+	Encoding_Console string = "console"
+	Encoding_Logfmt  string = "logfmt"
+
+	Schema_Default string = "default"
+	Schema_Gcp     string = "gcp"
+	Schema_Ecs     string = "ecs"
+	Schema_Otel    string = "otel"
+)
+
+var (
+	schema       = Schema_Default
+	gcpProjectId = ""
+)
+
+func newEncoder(conf Config) (zapcore.Encoder, error) {
+	encoderConfig, err := newEncoderConfig(conf.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoder zapcore.Encoder
+	switch conf.Encoding {
+	case "", Encoding_Json:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case Encoding_Console:
+		if conf.IsDevelopment {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case Encoding_Logfmt:
+		encoder = newLogfmtEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unknown log encoding: %s", conf.Encoding)
+	}
+
+	if conf.Schema == Schema_Otel {
+		encoder = &severityNumberEncoder{Encoder: encoder}
+	}
+	return encoder, nil
+}
+
+func newEncoderConfig(schema string) (zapcore.EncoderConfig, error) {
+	config := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "severity",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		FunctionKey:    zapcore.OmitKey,
+		MessageKey:     "message",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.MillisDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	switch schema {
+	case "", Schema_Default:
+	case Schema_Gcp:
+		// https://cloud.google.com/logging/docs/structured-logging
+		config.TimeKey = "timestamp"
+		config.EncodeLevel = gcpLevelEncoder
+		config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	case Schema_Ecs:
+		// https://www.elastic.co/guide/en/ecs/current/ecs-log.html
+		config.TimeKey = "@timestamp"
+		config.LevelKey = "log.level"
+		config.NameKey = "log.logger"
+		config.CallerKey = "log.origin.file.name"
+		config.StacktraceKey = "error.stack_trace"
+		config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	case Schema_Otel:
+		// https://opentelemetry.io/docs/reference/specification/logs/data-model/
+		config.TimeKey = "timestamp"
+		config.LevelKey = "severity_text"
+		config.NameKey = "instrumentation_scope"
+		config.CallerKey = "code.filepath"
+		config.MessageKey = "body"
+		config.StacktraceKey = "exception.stacktrace"
+		config.EncodeLevel = zapcore.CapitalLevelEncoder
+		config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	default:
+		return config, fmt.Errorf("unknown log schema: %s", schema)
+	}
+
+	return config, nil
+}
+
+// schemaFields returns the fields describing the application using the field names of the schema
+func schemaFields(conf Config, app map[string]string) []zap.Field {
+	switch conf.Schema {
+	case Schema_Ecs:
+		service := map[string]string{"name": conf.AppName}
+		if conf.Version != "" {
+			service["version"] = conf.Version
+		}
+
+		fields := []zap.Field{zap.String("ecs.version", "1.6.0"), zap.Any("service", service)}
+		kubernetes := map[string]interface{}{}
+		if conf.PodName != "" {
+			kubernetes["pod"] = map[string]string{"name": conf.PodName}
+		}
+		if conf.Namespace != "" {
+			kubernetes["namespace"] = conf.Namespace
+		}
+		if conf.NodeName != "" {
+			kubernetes["node"] = map[string]string{"name": conf.NodeName}
+		}
+		if len(kubernetes) > 0 {
+			fields = append(fields, zap.Any("kubernetes", kubernetes))
+		}
+		return fields
+	case Schema_Otel:
+		resource := map[string]string{"service.name": conf.AppName}
+		addIfSet(resource, "service.version", conf.Version)
+		addIfSet(resource, "k8s.pod.name", conf.PodName)
+		addIfSet(resource, "k8s.namespace.name", conf.Namespace)
+		addIfSet(resource, "k8s.node.name", conf.NodeName)
+		return []zap.Field{zap.Any("resource", resource)}
+	}
+
+	fields := []zap.Field{zap.Any("app", app)}
+	kubernetes := map[string]string{}
+	addIfSet(kubernetes, "pod", conf.PodName)
+	addIfSet(kubernetes, "namespace", conf.Namespace)
+	addIfSet(kubernetes, "node", conf.NodeName)
+	if len(kubernetes) > 0 {
+		fields = append(fields, zap.Any("k8s", kubernetes))
+	}
+	return fields
+}
+
+func addIfSet(values map[string]string, key string, value string) {
+	if value != "" {
+		values[key] = value
+	}
+}
+
+func gcpLevelEncoder(level zapcore.Level, encoder zapcore.PrimitiveArrayEncoder) {
+	switch level {
+	case zapcore.DebugLevel:
+		encoder.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		encoder.AppendString("INFO")
+	case zapcore.WarnLevel:
+		encoder.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		encoder.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		encoder.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		encoder.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		encoder.AppendString("EMERGENCY")
+	default:
+		encoder.AppendString("DEFAULT")
+	}
+}
+
+// severityNumberEncoder adds the numeric severity required by the OpenTelemetry log data model
+type severityNumberEncoder struct {
+	zapcore.Encoder
+}
+
+func (encoder *severityNumberEncoder) Clone() zapcore.Encoder {
+	return &severityNumberEncoder{Encoder: encoder.Encoder.Clone()}
+}
+
+func (encoder *severityNumberEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return encoder.Encoder.EncodeEntry(entry, append(fields, zap.Int("severity_number", otelSeverity(entry.Level))))
+}
+
+func otelSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	}
+	return 21
+}
+
+// TraceId returns a field correlating the log entry with a trace using the field name of the configured schema
+func TraceId(traceId string) zap.Field {
+	switch schema {
+	case Schema_Gcp:
+		if gcpProjectId != "" {
+			traceId = "projects/" + gcpProjectId + "/traces/" + traceId
+		}
+		return zap.String("logging.googleapis.com/trace", traceId)
+	case Schema_Ecs:
+		return zap.String("trace.id", traceId)
+	case Schema_Otel:
+		return zap.String("trace_id", traceId)
+	}
+	return zap.String("traceId", traceId)
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	stdlog "log"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/klog/v2"
+
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+)
+
+// The component names used by the libraries whose logs are redirected, their levels can be overridden
+// using log.levels.<component>
+const (
+	Component_Klog        string = "klog"
+	Component_Stdlib      string = "stdlib"
+	Component_CloudEvents string = "cloudevents"
+	Component_Opa         string = "opa"
+)
+
+var (
+	restoreStdlib func()
+	redirectMutex = &sync.Mutex{}
+)
+
+// redirectLibraries sends the logs written by client-go (klog) and the stdlib log package through the
+// logger so that they share its encoding, levels and redaction rules
+func redirectLibraries() {
+	redirectMutex.Lock()
+	defer redirectMutex.Unlock()
+
+	klog.SetLogger(logr.New(&klogSink{LogSink: zapr.NewLogger(Named(Component_Klog)).GetSink()}))
+
+	if restoreStdlib != nil {
+		restoreStdlib()
+	}
+	restore, err := zap.RedirectStdLogAt(Named(Component_Stdlib), zapcore.InfoLevel)
+	if err != nil {
+		stdlog.Printf("unable to redirect the standard logger: %s", err)
+		return
+	}
+	restoreStdlib = restore
+}
+
+// klogSink removes the trailing new line klog adds to every message
+type klogSink struct {
+	logr.LogSink
+}
+
+func (sink *klogSink) Info(level int, message string, keysAndValues ...interface{}) {
+	sink.LogSink.Info(level, strings.TrimSuffix(message, "\n"), keysAndValues...)
+}
+
+func (sink *klogSink) Error(err error, message string, keysAndValues ...interface{}) {
+	sink.LogSink.Error(err, strings.TrimSuffix(message, "\n"), keysAndValues...)
+}
+
+func (sink *klogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &klogSink{LogSink: sink.LogSink.WithValues(keysAndValues...)}
+}
+
+func (sink *klogSink) WithName(name string) logr.LogSink {
+	return &klogSink{LogSink: sink.LogSink.WithName(name)}
+}
+
+func (sink *klogSink) WithCallDepth(depth int) logr.LogSink {
+	if withCallDepth, ok := sink.LogSink.(logr.CallDepthLogSink); ok {
+		return &klogSink{LogSink: withCallDepth.WithCallDepth(depth)}
+	}
+	return sink
+}
+
+// WithCloudEventsLogger attaches the logger to the context so that the cloudevents SDK doesn't fall back to
+// its own production logger when sending or receiving events
+func WithCloudEventsLogger(ctx context.Context) context.Context {
+	return cecontext.WithLogger(ctx, Named(Component_CloudEvents).Sugar())
+}
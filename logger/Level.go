@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelSource returns the configured level for a component, an empty string uses the global level
+type LevelSource func(component string) string
+
+var (
+	// the global level is shared by every logger so that it can be changed without rebuilding them
+	level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	// configuredLevel is restored when the runtime override set by SetLevel is cleared
+	configuredLevel = "debug"
+	runtimeLevel    bool
+	levelMutex      = &sync.Mutex{}
+
+	components      = map[string]*componentLevel{}
+	componentSource LevelSource
+	componentMutex  = &sync.RWMutex{}
+)
+
+// componentLevel overrides the global level for a named logger when an override has been configured or
+// set at runtime, runtime overrides take precedence until they are cleared
+type componentLevel struct {
+	level      zap.AtomicLevel
+	override   bool
+	configured string
+	runtime    bool
+	mutex      *sync.RWMutex
+}
+
+func (component *componentLevel) Enabled(l zapcore.Level) bool {
+	component.mutex.RLock()
+	override := component.override
+	component.mutex.RUnlock()
+
+	if override {
+		return component.level.Enabled(l)
+	}
+	return level.Enabled(l)
+}
+
+// set applies the configured level unless it has been overridden at runtime
+func (component *componentLevel) set(value string) {
+	component.mutex.Lock()
+	defer component.mutex.Unlock()
+
+	component.configured = value
+	if !component.runtime {
+		component.apply(value)
+	}
+}
+
+// setRuntime overrides the configured level, an empty value restores it
+func (component *componentLevel) setRuntime(value string) {
+	component.mutex.Lock()
+	defer component.mutex.Unlock()
+
+	component.runtime = value != ""
+	if component.runtime {
+		component.apply(value)
+	} else {
+		component.apply(component.configured)
+	}
+}
+
+func (component *componentLevel) apply(value string) {
+	component.override = value != ""
+	if component.override {
+		component.level.SetLevel(getLogLevel(value))
+	}
+}
+
+// SetLevel overrides the global level at runtime, the override is kept when the configuration changes
+// until ClearLevel is called
+func SetLevel(value string) error {
+	parsed := zapcore.DebugLevel
+	if err := parsed.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+
+	levelMutex.Lock()
+	defer levelMutex.Unlock()
+	runtimeLevel = true
+	level.SetLevel(parsed)
+	return nil
+}
+
+// ClearLevel removes the runtime override and restores the configured level
+func ClearLevel() {
+	levelMutex.Lock()
+	defer levelMutex.Unlock()
+	runtimeLevel = false
+	level.SetLevel(getLogLevel(configuredLevel))
+}
+
+func GetLevel() string {
+	return level.String()
+}
+
+// SetComponentLevel overrides the level of the named logger at runtime, the override is kept when the
+// configuration changes. An empty level removes the override and restores the configured level
+func SetComponentLevel(component string, value string) error {
+	if value != "" {
+		parsed := zapcore.DebugLevel
+		if err := parsed.UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+	}
+
+	getComponentLevel(component).setRuntime(value)
+	return nil
+}
+
+// setConfiguredLevel applies the configured global level unless it has been overridden at runtime
+func setConfiguredLevel(value string) {
+	levelMutex.Lock()
+	defer levelMutex.Unlock()
+	configuredLevel = value
+	if !runtimeLevel {
+		level.SetLevel(getLogLevel(value))
+	}
+}
+
+// ConfigureLevels updates the global level and the level of every named logger from the source, the source
+// is retained so that loggers named later are also configured. Levels overridden at runtime are kept
+func ConfigureLevels(value string, source LevelSource) {
+	setConfiguredLevel(value)
+
+	componentMutex.Lock()
+	componentSource = source
+	levels := map[string]*componentLevel{}
+	for name, component := range components {
+		levels[name] = component
+	}
+	componentMutex.Unlock()
+
+	for name, component := range levels {
+		component.set(source(name))
+	}
+}
+
+func getComponentLevel(name string) *componentLevel {
+	componentMutex.RLock()
+	component, found := components[name]
+	componentMutex.RUnlock()
+	if found {
+		return component
+	}
+
+	componentMutex.Lock()
+	defer componentMutex.Unlock()
+	if component, found := components[name]; found {
+		return component
+	}
+
+	component = &componentLevel{
+		level: zap.NewAtomicLevel(),
+		mutex: &sync.RWMutex{},
+	}
+	if componentSource != nil {
+		component.set(componentSource(name))
+	}
+	components[name] = component
+	return component
+}
+
+// levelCore filters entries using the enabler rather than the level of the wrapped core so that named loggers
+// can log below the global level
+type levelCore struct {
+	zapcore.Core
+	enabler zapcore.LevelEnabler
+}
+
+func (core *levelCore) Enabled(l zapcore.Level) bool {
+	return core.enabler.Enabled(l)
+}
+
+func (core *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: core.Core.With(fields), enabler: core.enabler}
+}
+
+func (core *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !core.enabler.Enabled(entry.Level) {
+		return checked
+	}
+	return core.Core.Check(entry, checked)
+}
@@ -0,0 +1,20 @@
+package logger
+
+import "testing"
+
+func TestRuntimeLevelOverridesAreKept(t *testing.T) {
+	ConfigureLevels("info", func(component string) string { return "" })
+	if err := SetLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+
+	ConfigureLevels("debug", func(component string) string { return "" })
+	if GetLevel() != "error" {
+		t.Errorf("expected the runtime level to be kept when the configuration changes, got %s", GetLevel())
+	}
+
+	ClearLevel()
+	if GetLevel() != "debug" {
+		t.Errorf("expected the configured level to be restored, got %s", GetLevel())
+	}
+}
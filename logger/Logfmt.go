@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var bufferPool = buffer.NewPool()
+
+// logfmtEncoder renders the entry produced by the json encoder as logfmt so that every schema is supported,
+// nested objects are flattened into dotted keys, ie: http.statusCode=200
+type logfmtEncoder struct {
+	zapcore.Encoder
+	lineEnding string
+}
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	lineEnding := config.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	config.LineEnding = zapcore.DefaultLineEnding
+
+	return &logfmtEncoder{
+		Encoder:    zapcore.NewJSONEncoder(config),
+		lineEnding: lineEnding,
+	}
+}
+
+func (encoder *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{
+		Encoder:    encoder.Encoder.Clone(),
+		lineEnding: encoder.lineEnding,
+	}
+}
+
+func (encoder *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := encoder.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	line := bufferPool.Get()
+	if err := writeLogfmt(line, "", bytes.TrimSpace(encoded.Bytes())); err != nil {
+		line.Free()
+		return nil, err
+	}
+	line.AppendString(encoder.lineEnding)
+
+	return line, nil
+}
+
+func writeLogfmt(line *buffer.Buffer, prefix string, object []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(object))
+	decoder.UseNumber()
+
+	// consume the opening brace of the object
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + token.(string)
+
+		value := json.RawMessage{}
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+
+		if len(value) > 0 && value[0] == '{' {
+			if err := writeLogfmt(line, key+".", value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if line.Len() > 0 {
+			line.AppendByte(' ')
+		}
+		line.AppendString(key)
+		line.AppendByte('=')
+
+		text := string(value)
+		if len(value) > 0 && value[0] == '"' {
+			if err := json.Unmarshal(value, &text); err != nil {
+				return err
+			}
+		}
+		appendLogfmtValue(line, text)
+	}
+
+	return nil
+}
+
+func appendLogfmtValue(line *buffer.Buffer, value string) {
+	if value != "" && !strings.ContainsAny(value, " =\"\t\r\n") {
+		line.AppendString(value)
+		return
+	}
+
+	quoted, _ := json.Marshal(value)
+	line.Write(quoted)
+}
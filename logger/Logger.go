@@ -1,62 +1,141 @@
 package logger
 
 import (
+	"os"
 	"runtime"
+	"sync"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Logger buffers entries until Initialize or Use is called so that it is always safe to use
 var Logger *zap.Logger
 
+var (
+	// baseCore accepts every level, the levels are applied by the cores wrapping it
+	baseCore    zapcore.Core
+	named       = map[string]*zap.Logger{}
+	namedMutex  = &sync.RWMutex{}
+	initialized bool
+)
+
+// Initialize builds the logger, subsequent calls only apply the level so that loggers which have already
+// been captured by middleware and services stay valid. Use ConfigureLevels to change levels at runtime.
+// The logs of klog and the stdlib log package are redirected once the logger has been built
 func Initialize(conf Config) {
-	initialFields := map[string]interface{}{
-		"app": &map[string]string{
-			"name": conf.AppName,
-			"arch": runtime.GOARCH,
-			"os":   runtime.GOOS,
-		},
+	setConfiguredLevel(conf.LogLevel)
+
+	if build(conf) {
+		redirectLibraries()
 	}
+}
 
-	if conf.Version != "" {
-		m, _ := initialFields["app"].(map[string]string)
-		m["version"] = conf.Version
+func build(conf Config) bool {
+	namedMutex.Lock()
+	defer namedMutex.Unlock()
+	if initialized {
+		return false
 	}
 
-	if conf.CommitSha != "" {
-		m, _ := initialFields["app"].(map[string]string)
-		m["commit"] = conf.CommitSha
+	app := map[string]string{
+		"name": conf.AppName,
+		"arch": runtime.GOARCH,
+		"os":   runtime.GOOS,
 	}
 
-	config := zap.Config{
-		Level:            zap.NewAtomicLevelAt(getLogLevel(conf.LogLevel)),
-		Development:      conf.IsDevelopment,
-		Encoding:         "json",
-		OutputPaths:      []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
-		Sampling:         nil,
-		EncoderConfig: zapcore.EncoderConfig{
-			TimeKey:        "time",
-			LevelKey:       "severity",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			FunctionKey:    zapcore.OmitKey,
-			MessageKey:     "message",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.LowercaseLevelEncoder,
-			EncodeTime:     zapcore.ISO8601TimeEncoder,
-			EncodeDuration: zapcore.MillisDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		},
-		InitialFields: initialFields,
+	if conf.Version != "" {
+		app["version"] = conf.Version
 	}
 
-	logger, err := config.Build()
+	if conf.CommitSha != "" {
+		app["commit"] = conf.CommitSha
+	}
+
+	encoder, err := newEncoder(conf)
 	if err != nil {
 		panic(err)
 	}
-	Logger = logger
+	schema = conf.Schema
+	gcpProjectId = conf.GcpProjectId
+
+	options := []zap.Option{
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+		zap.Fields(schemaFields(conf, app)...),
+	}
+	if conf.IsDevelopment {
+		options = append(options, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
+	}
+
+	core := newSampledCore(encoder, newOutput(conf), conf.Sampling)
+	logger := zap.New(&redactionCore{Core: newDedupeCore(core, conf.DedupeWindow)}, options...)
+
+	baseCore = logger.Core()
+	named = map[string]*zap.Logger{}
+	Logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, enabler: level}
+	}))
+	initialized = true
+	bootstrap.flush(Logger.Core(), true)
+	return true
+}
+
+// Use replaces the logger with one that was built elsewhere, ie: by tests. The levels of the provided
+// logger are used as is but entries are still redacted
+func Use(logger *zap.Logger) {
+	use(logger)
+	redirectLibraries()
+}
+
+func use(logger *zap.Logger) {
+	namedMutex.Lock()
+	defer namedMutex.Unlock()
+
+	previous := Logger
+	Logger = WithRedaction(logger)
+	baseCore = nil
+	named = map[string]*zap.Logger{}
+	initialized = true
+
+	// loggers captured before this call forward to the new logger
+	bootstrap.flush(Logger.Core(), true)
+	previous.Sync()
+}
+
+// WithRedaction wraps a logger so that its entries are redacted in the same way as the global logger
+func WithRedaction(logger *zap.Logger) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactionCore{Core: core}
+	}))
+}
+
+// Named returns the logger for a component, its level is overridden by log.levels.<component> when configured
+func Named(component string) *zap.Logger {
+	namedMutex.RLock()
+	logger, found := named[component]
+	current := Logger
+	core := baseCore
+	namedMutex.RUnlock()
+	if found {
+		return logger
+	}
+	logger = current.Named(component)
+	if core != nil {
+		enabler := getComponentLevel(component)
+		logger = logger.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+			return &levelCore{Core: core, enabler: enabler}
+		}))
+	}
+
+	namedMutex.Lock()
+	defer namedMutex.Unlock()
+	if existing, found := named[component]; found {
+		return existing
+	}
+	named[component] = logger
+	return logger
 }
 
 func getLogLevel(level string) zapcore.Level {
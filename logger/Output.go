@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// newOutput combines the outputs, anything other than stdout or stderr is treated as a file path that is
+// rotated once it reaches the maximum size
+func newOutput(conf Config) zapcore.WriteSyncer {
+	outputs := conf.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{"stdout"}
+	}
+
+	syncers := []zapcore.WriteSyncer{}
+	for _, output := range outputs {
+		switch output {
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			syncers = append(syncers, zapcore.AddSync(&lumberjack.Logger{
+				Filename:   output,
+				MaxSize:    valueOrDefault(conf.File.MaxSizeMB, 100),
+				MaxBackups: conf.File.MaxBackups,
+				MaxAge:     conf.File.MaxAgeDays,
+				Compress:   conf.File.Compress,
+			}))
+		}
+	}
+
+	return zapcore.NewMultiWriteSyncer(syncers...)
+}
+
+func valueOrDefault(value int, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const Redacted string = "[REDACTED]"
+
+// RedactionConfig selects what is removed from log entries, Patterns are the names of the built in patterns
+// (bearer, jwt, email, card, query) and Regex is an optional custom pattern whose matches are redacted
+type RedactionConfig struct {
+	Disabled bool
+	Keys     []string
+	Patterns []string
+	Regex    string
+}
+
+type redactionPattern struct {
+	expression  *regexp.Regexp
+	replacement string
+	validate    func(match string) bool
+}
+
+type redactor struct {
+	disabled bool
+	keys     map[string]bool
+	patterns []redactionPattern
+}
+
+var (
+	DefaultRedactedKeys = []string{
+		"authorization", "password", "passwd", "secret", "token", "apikey", "api_key", "x-api-key",
+		"cookie", "set-cookie", "client_secret", "access_token", "refresh_token", "privatekey", "private_key",
+	}
+	DefaultRedactionPatterns = []string{"bearer", "jwt", "email", "card", "query"}
+
+	builtinPatterns = map[string]redactionPattern{
+		"bearer": {expression: regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), replacement: "${1}" + Redacted},
+		"jwt":    {expression: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), replacement: Redacted},
+		"email":  {expression: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), replacement: Redacted},
+		"card":   {expression: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), replacement: Redacted, validate: isLuhnValid},
+		"query":  {expression: regexp.MustCompile(`(?i)((?:access_token|refresh_token|token|password|secret|api[_-]?key|client_secret)=)[^&\s"]+`), replacement: "${1}" + Redacted},
+	}
+
+	currentRedactor = atomic.Value{}
+
+	// sensitiveValues are the values sourced from secrets, keyed by their source so they can be replaced
+	sensitiveValues = map[string][]string{}
+	sensitiveList   = atomic.Value{}
+	sensitiveMutex  = &sync.Mutex{}
+)
+
+func init() {
+	ConfigureRedaction(RedactionConfig{
+		Keys:     DefaultRedactedKeys,
+		Patterns: DefaultRedactionPatterns,
+	})
+	sensitiveList.Store([]string{})
+}
+
+// ConfigureRedaction replaces the redaction rules applied to every log entry
+func ConfigureRedaction(conf RedactionConfig) error {
+	redactor := &redactor{
+		disabled: conf.Disabled,
+		keys:     map[string]bool{},
+	}
+
+	for _, key := range conf.Keys {
+		redactor.keys[normaliseKey(key)] = true
+	}
+
+	for _, name := range conf.Patterns {
+		pattern, found := builtinPatterns[strings.ToLower(strings.TrimSpace(name))]
+		if !found {
+			return fmt.Errorf("unknown redaction pattern: %s", name)
+		}
+		redactor.patterns = append(redactor.patterns, pattern)
+	}
+
+	if conf.Regex != "" {
+		expression, err := regexp.Compile(conf.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid redaction regex: %w", err)
+		}
+		redactor.patterns = append(redactor.patterns, redactionPattern{expression: expression, replacement: Redacted})
+	}
+
+	currentRedactor.Store(redactor)
+	return nil
+}
+
+// SetSensitiveValues redacts the values wherever they appear in log entries, calling it again with the same
+// source replaces its values. Values shorter than 4 characters are ignored to avoid redacting common words
+func SetSensitiveValues(source string, values []string) {
+	sensitiveMutex.Lock()
+	defer sensitiveMutex.Unlock()
+
+	filtered := []string{}
+	for _, value := range values {
+		if len(value) >= 4 {
+			filtered = append(filtered, value)
+		}
+	}
+
+	if len(filtered) == 0 {
+		delete(sensitiveValues, source)
+	} else {
+		sensitiveValues[source] = filtered
+	}
+
+	list := []string{}
+	for _, values := range sensitiveValues {
+		list = append(list, values...)
+	}
+	sensitiveList.Store(list)
+}
+
+// RedactString applies the redaction rules to the value
+func RedactString(value string) string {
+	return currentRedactor.Load().(*redactor).redactString(value)
+}
+
+// RedactFields applies the redaction rules to fields that are encoded outside of the logger
+func RedactFields(fields []zapcore.Field) []zapcore.Field {
+	return currentRedactor.Load().(*redactor).redactFields(fields)
+}
+
+func (redactor *redactor) redactString(value string) string {
+	if redactor.disabled || value == "" {
+		return value
+	}
+
+	for _, sensitive := range sensitiveList.Load().([]string) {
+		if strings.Contains(value, sensitive) {
+			value = strings.ReplaceAll(value, sensitive, Redacted)
+		}
+	}
+
+	for _, pattern := range redactor.patterns {
+		if pattern.validate == nil {
+			value = pattern.expression.ReplaceAllString(value, pattern.replacement)
+			continue
+		}
+
+		value = pattern.expression.ReplaceAllStringFunc(value, func(match string) string {
+			if pattern.validate(match) {
+				return pattern.replacement
+			}
+			return match
+		})
+	}
+
+	return value
+}
+
+func (redactor *redactor) isSensitiveKey(key string) bool {
+	return redactor.keys[normaliseKey(key)]
+}
+
+func (redactor *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	if redactor.disabled || len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = redactor.redactField(field)
+	}
+	return redacted
+}
+
+func (redactor *redactor) redactField(field zapcore.Field) zapcore.Field {
+	if field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType {
+		return field
+	}
+	if redactor.isSensitiveKey(field.Key) {
+		return zap.String(field.Key, Redacted)
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = redactor.redactString(field.String)
+		return field
+	case zapcore.ByteStringType:
+		return zap.String(field.Key, redactor.redactString(string(field.Interface.([]byte))))
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			return zap.String(field.Key, redactor.redactString(err.Error()))
+		}
+		return field
+	case zapcore.StringerType:
+		if stringer, ok := field.Interface.(fmt.Stringer); ok && stringer != nil {
+			return zap.String(field.Key, redactor.redactString(stringer.String()))
+		}
+		return field
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		encoder := zapcore.NewMapObjectEncoder()
+		field.AddTo(encoder)
+		return zap.Any(field.Key, redactor.redactValue(toGeneric(encoder.Fields[field.Key])))
+	}
+
+	return field
+}
+
+func (redactor *redactor) redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return redactor.redactString(typed)
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if redactor.isSensitiveKey(key) {
+				typed[key] = Redacted
+			} else {
+				typed[key] = redactor.redactValue(nested)
+			}
+		}
+		return typed
+	case []interface{}:
+		for i, nested := range typed {
+			typed[i] = redactor.redactValue(nested)
+		}
+		return typed
+	}
+	return value
+}
+
+// toGeneric converts reflected values into maps, slices and primitives so that they can be walked
+func toGeneric(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool, float64, int, int64:
+		return value
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return value
+	}
+	return generic
+}
+
+func normaliseKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
+
+func isLuhnValid(value string) bool {
+	sum := 0
+	digits := 0
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		digit := int(c - '0')
+		if digits%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// redactionCore applies the current redaction rules to the message and fields of every entry
+type redactionCore struct {
+	zapcore.Core
+}
+
+func (core *redactionCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactionCore{Core: core.Core.With(currentRedactor.Load().(*redactor).redactFields(fields))}
+}
+
+func (core *redactionCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !core.Enabled(entry.Level) {
+		return checked
+	}
+	return checked.AddCore(entry, core)
+}
+
+func (core *redactionCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	redactor := currentRedactor.Load().(*redactor)
+	entry.Message = redactor.redactString(entry.Message)
+	return write(core.Core, entry, redactor.redactFields(fields))
+}
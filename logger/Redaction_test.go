@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func useDefaultRedaction(t *testing.T) {
+	configure := func() {
+		ConfigureRedaction(RedactionConfig{Keys: DefaultRedactedKeys, Patterns: DefaultRedactionPatterns})
+	}
+	configure()
+	t.Cleanup(configure)
+}
+
+func TestRedactStringAppliesThePatterns(t *testing.T) {
+	useDefaultRedaction(t)
+
+	cases := map[string]string{
+		"Authorization: Bearer abc.def-123":               "Authorization: Bearer " + Redacted,
+		"token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln": "token " + Redacted,
+		"sent to jane.doe@example.com":                    "sent to " + Redacted,
+		"paid with 4111 1111 1111 1111":                   "paid with " + Redacted,
+		"order 1234567890123 was created":                 "order 1234567890123 was created",
+		"/callback?code=1&access_token=abc&state=2":       "/callback?code=1&access_token=" + Redacted + "&state=2",
+	}
+
+	for value, expected := range cases {
+		if redacted := RedactString(value); redacted != expected {
+			t.Errorf("expected %q to be redacted as %q, got %q", value, expected, redacted)
+		}
+	}
+}
+
+func TestRedactStringRemovesSensitiveValues(t *testing.T) {
+	useDefaultRedaction(t)
+	SetSensitiveValues("test", []string{"s3cr3t-value", "abc"})
+	t.Cleanup(func() {
+		SetSensitiveValues("test", nil)
+	})
+
+	if redacted := RedactString("connecting with s3cr3t-value to abc"); redacted != "connecting with "+Redacted+" to abc" {
+		t.Errorf("expected only the sensitive values of 4 characters or more to be redacted, got %q", redacted)
+	}
+
+	SetSensitiveValues("test", nil)
+	if redacted := RedactString("s3cr3t-value"); redacted != "s3cr3t-value" {
+		t.Errorf("expected the values to be removed with their source, got %q", redacted)
+	}
+}
+
+func TestRedactionCoreRedactsFields(t *testing.T) {
+	useDefaultRedaction(t)
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&redactionCore{Core: observed})
+
+	logger.With(zap.String("Api-Key", "key")).Info("login for jane.doe@example.com",
+		zap.String("password", "hunter2"),
+		zap.Error(errors.New("invalid token=abc")),
+		zap.Any("request", map[string]interface{}{
+			"headers": map[string]interface{}{"authorization": "Basic abc"},
+			"path":    "/orders",
+		}),
+	)
+
+	entry := logs.All()[0]
+	if strings.Contains(entry.Message, "jane.doe") {
+		t.Errorf("expected the message to be redacted, got %q", entry.Message)
+	}
+
+	fields := entry.ContextMap()
+	if fields["Api-Key"] != Redacted || fields["password"] != Redacted {
+		t.Errorf("expected the sensitive keys to be redacted, got %v", fields)
+	}
+	if fields["error"] != "invalid token="+Redacted {
+		t.Errorf("expected the error to be redacted, got %v", fields["error"])
+	}
+
+	request := fields["request"].(map[string]interface{})
+	if request["headers"].(map[string]interface{})["authorization"] != Redacted || request["path"] != "/orders" {
+		t.Errorf("expected only the nested sensitive keys to be redacted, got %v", request)
+	}
+}
+
+func TestRedactionCanBeDisabled(t *testing.T) {
+	useDefaultRedaction(t)
+	ConfigureRedaction(RedactionConfig{Disabled: true, Keys: DefaultRedactedKeys, Patterns: DefaultRedactionPatterns})
+
+	if redacted := RedactString("jane.doe@example.com"); redacted != "jane.doe@example.com" {
+		t.Errorf("expected nothing to be redacted, got %q", redacted)
+	}
+}
+
+func TestConfigureRedactionRejectsUnknownPatterns(t *testing.T) {
+	useDefaultRedaction(t)
+
+	if err := ConfigureRedaction(RedactionConfig{Patterns: []string{"unknown"}}); err == nil {
+		t.Errorf("expected an error for an unknown pattern")
+	}
+	if err := ConfigureRedaction(RedactionConfig{Regex: "("}); err == nil {
+		t.Errorf("expected an error for an invalid regex")
+	}
+}
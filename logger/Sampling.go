@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig limits the entries logged per second for each level and message, the first Initial entries
+// in each Tick are logged and then every Thereafter entry
+type SamplingConfig struct {
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+	// Levels are the levels that are sampled, defaults to debug and info
+	Levels []string
+}
+
+// newSampledCore samples the configured levels and passes all other levels straight through
+func newSampledCore(encoder zapcore.Encoder, output zapcore.WriteSyncer, conf SamplingConfig) zapcore.Core {
+	if conf.Initial <= 0 {
+		return zapcore.NewCore(encoder, output, zapcore.DebugLevel)
+	}
+
+	levels := conf.Levels
+	if len(levels) == 0 {
+		levels = []string{"debug", "info"}
+	}
+
+	sampled := map[zapcore.Level]bool{}
+	for _, value := range levels {
+		sampled[getLogLevel(value)] = true
+	}
+
+	tick := conf.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	sampledCore := zapcore.NewCore(encoder.Clone(), output, zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return sampled[l]
+	}))
+	unsampledCore := zapcore.NewCore(encoder, output, zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return !sampled[l]
+	}))
+
+	return zapcore.NewTee(
+		zapcore.NewSamplerWithOptions(sampledCore, tick, conf.Initial, conf.Thereafter),
+		unsampledCore,
+	)
+}
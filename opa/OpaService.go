@@ -6,6 +6,10 @@ import (
 	"strconv"
 
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown/print"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
 )
 
 const SERVICE_NAME string = "OPA"
@@ -14,6 +18,13 @@ type OPAService struct {
 	policies map[string]rego.PreparedEvalQuery
 }
 
+// New creates the service with its policies initialised so that copies of the service share them
+func New() *OPAService {
+	return &OPAService{
+		policies: map[string]rego.PreparedEvalQuery{},
+	}
+}
+
 func (opa *OPAService) AddOrUpdatePolicy(namespace string, name string, outputParameters map[string]interface{}, policy string) error {
 	if opa.policies == nil {
 		opa.policies = map[string]rego.PreparedEvalQuery{}
@@ -49,6 +60,8 @@ func (opa *OPAService) AddOrUpdatePolicy(namespace string, name string, outputPa
 	query, err := rego.New(
 		rego.Query(outputQuery),
 		rego.Module(namespace+".rego", completePolicy),
+		rego.EnablePrintStatements(true),
+		rego.PrintHook(printHook{}),
 	).PrepareForEval(context.TODO())
 
 	if err != nil {
@@ -97,6 +110,18 @@ func (opa *OPAService) RemovePolicy(namespace string, name string) {
 	delete(opa.policies, formatPolicyKey(namespace, name))
 }
 
+// printHook writes the output of print() calls in policies to the opa component logger
+type printHook struct{}
+
+func (printHook) Print(ctx print.Context, message string) error {
+	fields := []zap.Field{}
+	if ctx.Location != nil {
+		fields = append(fields, zap.String("location", ctx.Location.String()))
+	}
+	log.Named(log.Component_Opa).Debug(message, fields...)
+	return nil
+}
+
 func formatPolicyKey(namespace string, name string) string {
 	return fmt.Sprintf("%s|%s", namespace, name)
 }
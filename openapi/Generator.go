@@ -0,0 +1,195 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+// Generate builds the document from the routes registered with the app, merging in the documentation from
+// the registry. Middleware, routes registered for every method, HEAD routes generated for GET routes and system
+// routes are excluded
+func Generate(info Info, app *fiber.App, registry *Registry) *Document {
+	generator := newSchemaGenerator()
+	problemSchema := generator.schemaFor(reflect.TypeOf(problems.Problem{}))
+
+	document := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+	}
+
+	for _, route := range routes(app) {
+		path, parameters := convertPath(route.Path)
+		item, found := document.Paths[path]
+		if !found {
+			item = &PathItem{}
+			document.Paths[path] = item
+		}
+
+		descriptor := registry.get(route.Method, route.Path)
+		if descriptor == nil {
+			descriptor = &operationDescriptor{operation: &Operation{}}
+		}
+
+		operation := *descriptor.operation
+		operation.Parameters = append(parameters, queryParameters(generator, descriptor.query)...)
+		operation.Responses = map[string]*Response{}
+
+		if descriptor.request != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					fiber.MIMEApplicationJSON: {Schema: generator.schemaFor(descriptor.request)},
+				},
+			}
+		}
+
+		for status, response := range descriptor.responses {
+			operation.Responses[strconv.Itoa(status)] = newResponse(generator, response)
+		}
+		if len(operation.Responses) == 0 {
+			operation.Responses[strconv.Itoa(fiber.StatusOK)] = &Response{Description: "OK"}
+		}
+		operation.Responses["default"] = &Response{
+			Description: "Error",
+			Content: map[string]MediaType{
+				problems.ContentType: {Schema: problemSchema},
+			},
+		}
+
+		(*item)[strings.ToLower(route.Method)] = &operation
+	}
+
+	if len(generator.components) > 0 {
+		document.Components = &Components{Schemas: generator.components}
+	}
+
+	return document
+}
+
+func routes(app *fiber.App) []*fiber.Route {
+	stacks := app.Stack()
+	methods := map[string]int{}
+	for _, stack := range stacks {
+		for _, route := range stack {
+			methods[handlerKey(route)]++
+		}
+	}
+
+	getRoutes := map[string]bool{}
+	result := []*fiber.Route{}
+	for _, stack := range stacks {
+		for _, route := range stack {
+			if methods[handlerKey(route)] == len(stacks) || route.Path == "/_system" || strings.HasPrefix(route.Path, "/_system/") {
+				continue
+			}
+			if route.Method == fiber.MethodGet {
+				getRoutes[route.Path] = true
+			}
+			result = append(result, route)
+		}
+	}
+
+	filtered := result[:0]
+	for _, route := range result {
+		if route.Method == fiber.MethodHead && getRoutes[route.Path] {
+			continue
+		}
+		filtered = append(filtered, route)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Path < filtered[j].Path
+	})
+
+	return filtered
+}
+
+// handlerKey identifies the handlers registered for a path. Routes registered with app.Use, or app.All, are
+// copied into the stack of every method with the same handlers and are excluded as they aren't operations
+func handlerKey(route *fiber.Route) string {
+	key := route.Path
+	for _, handler := range route.Handlers {
+		key += fmt.Sprintf("|%x", reflect.ValueOf(handler).Pointer())
+	}
+	return key
+}
+
+// convertPath converts the fiber path syntax into the OpenAPI path template, ie: /orders/:id => /orders/{id}
+func convertPath(path string) (string, []Parameter) {
+	parameters := []Parameter{}
+	wildcards := 0
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		name := ""
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name = strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+		case segment == "*" || segment == "+":
+			wildcards++
+			name = "path"
+			if wildcards > 1 {
+				name += strconv.Itoa(wildcards)
+			}
+		default:
+			continue
+		}
+
+		segments[i] = "{" + name + "}"
+		parameters = append(parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), parameters
+}
+
+// queryParameters documents the fields of the struct, Registry.Document rejects query types that aren't structs
+func queryParameters(generator *schemaGenerator, t reflect.Type) []Parameter {
+	if t == nil {
+		return nil
+	}
+	t = indirectType(t)
+
+	parameters := []Parameter{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("query"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := generator.schemaFor(field.Type)
+		required := applyValidation(schema, field.Type, field.Tag.Get("validate"))
+		parameters = append(parameters, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: field.Tag.Get("description"),
+			Required:    required,
+			Schema:      schema,
+		})
+	}
+	return parameters
+}
+
+func newResponse(generator *schemaGenerator, descriptor responseDescriptor) *Response {
+	response := &Response{Description: descriptor.description}
+	if descriptor.body != nil {
+		response.Content = map[string]MediaType{
+			fiber.MIMEApplicationJSON: {Schema: generator.schemaFor(descriptor.body)},
+		}
+	}
+	return response
+}
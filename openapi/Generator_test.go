@@ -0,0 +1,67 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type orderQuery struct {
+	Status string `query:"status"`
+}
+
+func handler(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func TestGenerateExcludesMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(handler)
+	app.Use("/orders", handler)
+	app.Get("/orders/:id", handler)
+
+	registry := NewRegistry()
+	registry.Document(fiber.MethodGet, "/orders/:id", Query[orderQuery]())
+
+	document := Generate(Info{Title: "test"}, app, registry)
+
+	if len(document.Paths) != 1 {
+		t.Fatalf("expected only the route to be documented, got %v", document.Paths)
+	}
+	operation := (*document.Paths["/orders/{id}"])["get"]
+	if operation == nil {
+		t.Fatalf("expected the route to be documented, got %v", document.Paths)
+	}
+	if len(operation.Parameters) != 2 || operation.Parameters[1].Name != "status" {
+		t.Errorf("expected the path and query parameters, got %v", operation.Parameters)
+	}
+}
+
+func TestDocumentRejectsQueriesThatArentStructs(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected documenting a query that isn't a struct to panic")
+		}
+	}()
+
+	NewRegistry().Document(fiber.MethodGet, "/orders", Query[string]())
+}
+
+func TestIntegersAreDocumentedBySize(t *testing.T) {
+	generator := newSchemaGenerator()
+	cases := map[reflect.Type]string{
+		reflect.TypeOf(int(0)):    "int64",
+		reflect.TypeOf(uint(0)):   "int64",
+		reflect.TypeOf(int64(0)):  "int64",
+		reflect.TypeOf(uint32(0)): "int64",
+		reflect.TypeOf(int32(0)):  "int32",
+		reflect.TypeOf(int16(0)):  "int32",
+	}
+
+	for integer, format := range cases {
+		if schema := generator.schemaFor(integer); schema.Type != "integer" || schema.Format != format {
+			t.Errorf("expected %s to be documented as %s, got %s", integer, format, schema.Format)
+		}
+	}
+}
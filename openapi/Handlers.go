@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/binding"
+)
+
+// Empty is used as the request or response type of typed handlers that don't have a body
+type Empty struct{}
+
+var emptyType = reflect.TypeOf(Empty{})
+
+// TypedHandler receives the bound and validated request, a nil response is sent as 204 No Content
+type TypedHandler[TRequest any, TResponse any] func(c *fiber.Ctx, request *TRequest) (*TResponse, error)
+
+// Status sets the status code returned by a typed handler when it succeeds, defaults to 200
+func Status(status int) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.status = status
+	}
+}
+
+// Handle registers a typed handler with fiber and documents its request and response types. Requests for
+// methods without a body are bound from the query string, all others are bound from the body
+func Handle[TRequest any, TResponse any](router fiber.Router, registry *Registry, method string, path string, handler TypedHandler[TRequest, TResponse], options ...OperationOption) fiber.Router {
+	method = strings.ToUpper(method)
+	requestType := reflect.TypeOf((*TRequest)(nil)).Elem()
+	responseType := reflect.TypeOf((*TResponse)(nil)).Elem()
+
+	descriptor := newOperationDescriptor()
+	descriptor.status = fiber.StatusOK
+	for _, option := range options {
+		option(descriptor)
+	}
+	status := descriptor.status
+
+	documentation := []OperationOption{}
+	if requestType != emptyType {
+		if hasBody(method) {
+			documentation = append(documentation, Request[TRequest]())
+		} else {
+			documentation = append(documentation, Query[TRequest]())
+		}
+	}
+	if responseType != emptyType {
+		documentation = append(documentation, Returns[TResponse](status, ""))
+	} else {
+		documentation = append(documentation, ReturnsEmpty(fiber.StatusNoContent, ""))
+	}
+	registry.Document(method, routePath(router, path), append(documentation, options...)...)
+
+	return router.Add(method, path, func(c *fiber.Ctx) error {
+		request := new(TRequest)
+		if requestType != emptyType {
+			var err error
+			if hasBody(method) {
+				request, err = binding.BindBody[TRequest](c)
+			} else {
+				request, err = binding.BindQuery[TRequest](c)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		response, err := handler(c, request)
+		if err != nil {
+			return err
+		}
+		if response == nil || responseType == emptyType {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		return c.Status(status).JSON(response)
+	})
+}
+
+func Get[TRequest any, TResponse any](router fiber.Router, registry *Registry, path string, handler TypedHandler[TRequest, TResponse], options ...OperationOption) fiber.Router {
+	return Handle(router, registry, fiber.MethodGet, path, handler, options...)
+}
+
+func Post[TRequest any, TResponse any](router fiber.Router, registry *Registry, path string, handler TypedHandler[TRequest, TResponse], options ...OperationOption) fiber.Router {
+	return Handle(router, registry, fiber.MethodPost, path, handler, options...)
+}
+
+func Put[TRequest any, TResponse any](router fiber.Router, registry *Registry, path string, handler TypedHandler[TRequest, TResponse], options ...OperationOption) fiber.Router {
+	return Handle(router, registry, fiber.MethodPut, path, handler, options...)
+}
+
+func Patch[TRequest any, TResponse any](router fiber.Router, registry *Registry, path string, handler TypedHandler[TRequest, TResponse], options ...OperationOption) fiber.Router {
+	return Handle(router, registry, fiber.MethodPatch, path, handler, options...)
+}
+
+func Delete[TRequest any, TResponse any](router fiber.Router, registry *Registry, path string, handler TypedHandler[TRequest, TResponse], options ...OperationOption) fiber.Router {
+	return Handle(router, registry, fiber.MethodDelete, path, handler, options...)
+}
+
+func hasBody(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodDelete, fiber.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// routePath resolves the path fiber registers the route under so that it matches when the document is generated
+func routePath(router fiber.Router, path string) string {
+	group, isGroup := router.(*fiber.Group)
+	if !isGroup {
+		return path
+	}
+	if path == "/" {
+		return group.Prefix
+	}
+	return strings.TrimRight(group.Prefix, "/") + path
+}
@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Registry holds the documentation for routes that is merged with the routes registered in fiber when the
+// document is generated
+type Registry struct {
+	operations map[string]*operationDescriptor
+	mutex      *sync.RWMutex
+}
+
+type operationDescriptor struct {
+	operation *Operation
+	request   reflect.Type
+	query     reflect.Type
+	responses map[int]responseDescriptor
+	status    int
+}
+
+type responseDescriptor struct {
+	description string
+	body        reflect.Type
+}
+
+type OperationOption func(*operationDescriptor)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		operations: map[string]*operationDescriptor{},
+		mutex:      &sync.RWMutex{},
+	}
+}
+
+// Document describes a route that was registered with fiber, the path must use the fiber syntax, ie: /orders/:id.
+// It panics when the query parameters are declared with a type that isn't a struct, in the same way that fiber
+// panics when a route is registered with an invalid handler
+func (registry *Registry) Document(method string, path string, options ...OperationOption) {
+	descriptor := newOperationDescriptor()
+	for _, option := range options {
+		option(descriptor)
+	}
+	if descriptor.query != nil && indirectType(descriptor.query).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("openapi: the query parameters of %s %s must be declared using a struct, got %s", strings.ToUpper(method), path, descriptor.query))
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.operations[operationKey(method, path)] = descriptor
+}
+
+func newOperationDescriptor() *operationDescriptor {
+	return &operationDescriptor{
+		operation: &Operation{},
+		responses: map[int]responseDescriptor{},
+	}
+}
+
+func (registry *Registry) get(method string, path string) *operationDescriptor {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return registry.operations[operationKey(method, path)]
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func operationKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func Summary(summary string) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.operation.Summary = summary
+	}
+}
+
+func Description(description string) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.operation.Description = description
+	}
+}
+
+func OperationId(id string) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.operation.OperationId = id
+	}
+}
+
+func Tags(tags ...string) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.operation.Tags = append(descriptor.operation.Tags, tags...)
+	}
+}
+
+func Deprecated() OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.operation.Deprecated = true
+	}
+}
+
+// Request declares the JSON request body of the operation
+func Request[T any]() OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.request = reflect.TypeOf((*T)(nil)).Elem()
+	}
+}
+
+// Query declares the query parameters of the operation from the `query` tags of T
+func Query[T any]() OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.query = reflect.TypeOf((*T)(nil)).Elem()
+	}
+}
+
+// Returns declares a JSON response body for the status code
+func Returns[T any](status int, description string) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.responses[status] = responseDescriptor{
+			description: responseDescription(status, description),
+			body:        reflect.TypeOf((*T)(nil)).Elem(),
+		}
+	}
+}
+
+// ReturnsEmpty declares a response without a body for the status code
+func ReturnsEmpty(status int, description string) OperationOption {
+	return func(descriptor *operationDescriptor) {
+		descriptor.responses[status] = responseDescriptor{
+			description: responseDescription(status, description),
+		}
+	}
+}
+
+func responseDescription(status int, description string) string {
+	if description == "" {
+		return http.StatusText(status)
+	}
+	return description
+}
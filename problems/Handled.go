@@ -0,0 +1,17 @@
+package problems
+
+import "github.com/gofiber/fiber/v2"
+
+const handledErrorKey string = "keas.problems.handled"
+
+// SetHandledError records an error that was rendered by middleware, ie: so that the response can be stored,
+// rather than returned through the chain so that it's still available to the access log
+func SetHandledError(c *fiber.Ctx, err error) {
+	c.Locals(handledErrorKey, err)
+}
+
+// GetHandledError returns the error recorded by SetHandledError, nil when there isn't one
+func GetHandledError(c *fiber.Ctx) error {
+	err, _ := c.Locals(handledErrorKey).(error)
+	return err
+}
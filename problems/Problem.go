@@ -0,0 +1,182 @@
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const ContentType string = "application/problem+json"
+
+const (
+	Code_Validation        string = "validation_failed"
+	Code_NotFound          string = "not_found"
+	Code_Conflict          string = "conflict"
+	Code_Unauthorized      string = "unauthorized"
+	Code_Forbidden         string = "forbidden"
+	Code_DependencyFailure string = "dependency_failure"
+	Code_Internal          string = "internal_error"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// Problem is an application error that is rendered as RFC 7807 application/problem+json
+type Problem struct {
+	Type      string                 `json:"type,omitempty"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Errors    []FieldError           `json:"errors,omitempty"`
+	RequestId string                 `json:"requestId,omitempty"`
+	TraceId   string                 `json:"traceId,omitempty"`
+
+	// Internal is only populated in development as it may contain sensitive information
+	Internal string `json:"internal,omitempty"`
+
+	cause error
+}
+
+// Mapper converts errors from third party packages into problems, returning false when the error isn't handled
+type Mapper func(err error) (*Problem, bool)
+
+func New(status int, code string, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+func NewValidationError(detail string, fields ...FieldError) *Problem {
+	problem := New(http.StatusBadRequest, Code_Validation, detail)
+	problem.Errors = fields
+	return problem
+}
+
+func NewNotFoundError(resource string, id string) *Problem {
+	return New(http.StatusNotFound, Code_NotFound, fmt.Sprintf("%s '%s' could not be found", resource, id)).
+		WithDetail("resource", resource).
+		WithDetail("id", id)
+}
+
+func NewConflictError(detail string) *Problem {
+	return New(http.StatusConflict, Code_Conflict, detail)
+}
+
+func NewUnauthorizedError(detail string) *Problem {
+	return New(http.StatusUnauthorized, Code_Unauthorized, detail)
+}
+
+func NewForbiddenError(detail string) *Problem {
+	return New(http.StatusForbidden, Code_Forbidden, detail)
+}
+
+// NewDependencyFailureError reports that a downstream dependency failed, the cause is only rendered in development
+func NewDependencyFailureError(dependency string, cause error) *Problem {
+	return New(http.StatusBadGateway, Code_DependencyFailure, fmt.Sprintf("the dependency '%s' failed to process the request", dependency)).
+		WithDetail("dependency", dependency).
+		WithCause(cause)
+}
+
+func (problem *Problem) WithCode(code string) *Problem {
+	problem.Code = code
+	return problem
+}
+
+func (problem *Problem) WithType(problemType string) *Problem {
+	problem.Type = problemType
+	return problem
+}
+
+func (problem *Problem) WithDetail(key string, value interface{}) *Problem {
+	if problem.Details == nil {
+		problem.Details = map[string]interface{}{}
+	}
+	problem.Details[key] = value
+	return problem
+}
+
+func (problem *Problem) WithFieldError(field string, message string, code string) *Problem {
+	problem.Errors = append(problem.Errors, FieldError{
+		Field:   field,
+		Message: message,
+		Code:    code,
+	})
+	return problem
+}
+
+func (problem *Problem) WithCause(err error) *Problem {
+	problem.cause = err
+	return problem
+}
+
+func (problem *Problem) Error() string {
+	message := fmt.Sprintf("%d %s", problem.Status, problem.Title)
+	if problem.Detail != "" {
+		message += ": " + problem.Detail
+	}
+	if problem.cause != nil {
+		message += ": " + problem.cause.Error()
+	}
+	return message
+}
+
+// clone copies the problem including its details and field errors so that the copy can be modified
+func (problem *Problem) clone() *Problem {
+	copy := *problem
+	if problem.Details != nil {
+		copy.Details = make(map[string]interface{}, len(problem.Details))
+		for key, value := range problem.Details {
+			copy.Details[key] = value
+		}
+	}
+	if problem.Errors != nil {
+		copy.Errors = append([]FieldError(nil), problem.Errors...)
+	}
+	return &copy
+}
+
+func (problem *Problem) Unwrap() error {
+	return problem.cause
+}
+
+// From converts any error into a problem using, in order, the problem in the error chain, the mappers and
+// finally the status code of a *fiber.Error. Anything else is treated as an internal error. Problems from the
+// error chain and mappers are copied so that they can be shared, ie: package level problems
+func From(err error, mappers ...Mapper) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem.clone()
+	}
+
+	for _, mapper := range mappers {
+		if mapped, ok := mapper(err); ok && mapped != nil {
+			mapped = mapped.clone()
+			if mapped.cause == nil {
+				mapped.cause = err
+			}
+			return mapped
+		}
+	}
+
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		problem = New(fiberError.Code, "", "")
+		if fiberError.Code < http.StatusInternalServerError && fiberError.Message != problem.Title {
+			problem.Detail = fiberError.Message
+		}
+		return problem.WithCause(err)
+	}
+
+	return New(http.StatusInternalServerError, Code_Internal, "An error occurred whilst processing your request. Please check the logs for more information.").WithCause(err)
+}
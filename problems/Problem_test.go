@@ -0,0 +1,72 @@
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errNotFound = errors.New("record not found")
+
+func TestFrom(t *testing.T) {
+	shared := New(http.StatusNotFound, Code_NotFound, "the order could not be found").WithDetail("resource", "order")
+	mapper := func(err error) (*Problem, bool) {
+		if errors.Is(err, errNotFound) {
+			return shared, true
+		}
+		return nil, false
+	}
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+		cause  bool
+	}{
+		{name: "problem", err: NewConflictError("the order has been shipped"), status: http.StatusConflict, code: Code_Conflict, detail: "the order has been shipped"},
+		{name: "wrapped problem", err: fmt.Errorf("saving order: %w", NewForbiddenError("denied")), status: http.StatusForbidden, code: Code_Forbidden, detail: "denied"},
+		{name: "mapped error", err: fmt.Errorf("loading order: %w", errNotFound), status: http.StatusNotFound, code: Code_NotFound, detail: "the order could not be found", cause: true},
+		{name: "client fiber error", err: fiber.NewError(http.StatusBadRequest, "missing id"), status: http.StatusBadRequest, detail: "missing id", cause: true},
+		{name: "server fiber error", err: fiber.NewError(http.StatusServiceUnavailable, "pool exhausted"), status: http.StatusServiceUnavailable, cause: true},
+		{name: "unknown error", err: errors.New("boom"), status: http.StatusInternalServerError, code: Code_Internal, cause: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			problem := From(tc.err, mapper)
+			if problem.Status != tc.status || problem.Code != tc.code {
+				t.Errorf("expected %d %q, got %d %q", tc.status, tc.code, problem.Status, problem.Code)
+			}
+			if tc.detail != "" && problem.Detail != tc.detail {
+				t.Errorf("expected the detail %q, got %q", tc.detail, problem.Detail)
+			}
+			if problem.Status >= http.StatusInternalServerError && tc.code != Code_Internal && problem.Detail != "" {
+				t.Errorf("expected server errors not to expose their message, got %q", problem.Detail)
+			}
+			if tc.cause && !errors.Is(problem, tc.err) {
+				t.Errorf("expected the problem to keep the original error as its cause")
+			}
+		})
+	}
+}
+
+func TestFromCopiesProblems(t *testing.T) {
+	shared := NewValidationError("invalid", FieldError{Field: "name", Message: "is required"}).WithDetail("resource", "order")
+	mapper := func(err error) (*Problem, bool) {
+		return shared, true
+	}
+
+	for _, problem := range []*Problem{From(shared), From(errors.New("mapped"), mapper)} {
+		problem.Instance = "/orders"
+		problem.WithDetail("id", "1").WithFieldError("quantity", "is required", "required")
+	}
+
+	if shared.Instance != "" || len(shared.Details) != 1 || len(shared.Errors) != 1 || shared.cause != nil {
+		t.Errorf("expected the shared problem to be left unchanged, got %+v", shared)
+	}
+}
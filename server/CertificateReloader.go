@@ -0,0 +1,130 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap"
+)
+
+// certificateReloader serves the current certificate for every TLS handshake so that rotated
+// certificates are picked up without restarting the listener
+type certificateReloader struct {
+	certificate atomic.Value
+	reload      func() error
+	logger      *zap.Logger
+}
+
+func (reloader *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if reloader.reload != nil {
+		err := reloader.reload()
+		if err != nil {
+			reloader.logger.Error("Unable to reload TLS certificate", zap.Error(err))
+		}
+	}
+
+	certificate, _ := reloader.certificate.Load().(*tls.Certificate)
+	if certificate == nil {
+		return nil, fmt.Errorf("no TLS certificate has been loaded")
+	}
+
+	return certificate, nil
+}
+
+// newFileCertificateReloader checks the modification time of the certificate and key at most once per
+// interval, reloading the pair when either file has changed
+func newFileCertificateReloader(certFile string, keyFile string, interval time.Duration, logger *zap.Logger) (*certificateReloader, error) {
+	reloader := &certificateReloader{logger: logger}
+
+	mutex := &sync.Mutex{}
+	lastCheck := time.Time{}
+	lastModified := time.Time{}
+
+	load := func() error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if time.Since(lastCheck) < interval {
+			return nil
+		}
+		lastCheck = time.Now()
+
+		modified, err := getLatestModificationTime(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		if !modified.After(lastModified) {
+			return nil
+		}
+
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+
+		lastModified = modified
+		reloader.certificate.Store(&certificate)
+		logger.Info("Loaded TLS certificate", zap.String("certFile", certFile), zap.String("keyFile", keyFile))
+		return nil
+	}
+
+	err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	reloader.reload = load
+	return reloader, nil
+}
+
+// newSecretCertificateReloader reads the tls.crt and tls.key entries from the named Kubernetes Secret
+// provider, reloading the pair whenever the configuration changes
+func newSecretCertificateReloader(config *configuration.ConfigurationRoot, secretName string, logger *zap.Logger) *certificateReloader {
+	reloader := &certificateReloader{logger: logger}
+
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		for _, provider := range c.Providers {
+			if provider.Type() != "KubernetesSecret" || provider.Name() != secretName {
+				continue
+			}
+
+			certFound, cert := provider.TryGetValue("tls.crt")
+			keyFound, key := provider.TryGetValue("tls.key")
+			if !certFound || !keyFound {
+				return
+			}
+
+			certificate, err := tls.X509KeyPair([]byte(cert), []byte(key))
+			if err != nil {
+				logger.Error("Unable to load TLS certificate from secret", zap.Error(err), zap.String("secret", secretName))
+				return
+			}
+
+			reloader.certificate.Store(&certificate)
+			return
+		}
+	})
+
+	return reloader
+}
+
+func getLatestModificationTime(files ...string) (time.Time, error) {
+	latest := time.Time{}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
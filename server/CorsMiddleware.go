@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+// NewCorsMiddleware applies the server.cors.* configuration keys. Credentials can only be allowed for an
+// explicit list of origins, a wildcard origin with credentials is rejected and the previous configuration
+// is kept as fiber would otherwise allow credentialed requests from any origin
+func NewCorsMiddleware(config *configuration.ConfigurationRoot) fiber.Handler {
+	return newReloadableMiddleware(config, func(c configuration.ConfigurationRoot) (fiber.Handler, error) {
+		origins := c.GetStringSliceValueOrDefault("server.cors.allowedOrigins", []string{"*"})
+		allowCredentials := c.GetBooleanValueOrDefault("server.cors.allowCredentials", false)
+		if allowCredentials {
+			for _, origin := range origins {
+				if strings.Contains(origin, "*") {
+					return nil, fmt.Errorf("server.cors.allowCredentials requires server.cors.allowedOrigins to list the origins explicitly")
+				}
+			}
+		}
+
+		return cors.New(cors.Config{
+			Next:             isSystemRoute,
+			AllowOrigins:     strings.Join(origins, ","),
+			AllowMethods:     strings.Join(c.GetStringSliceValueOrDefault("server.cors.allowedMethods", strings.Split(cors.ConfigDefault.AllowMethods, ",")), ","),
+			AllowHeaders:     strings.Join(c.GetStringSliceValueOrDefault("server.cors.allowedHeaders", []string{}), ","),
+			ExposeHeaders:    strings.Join(c.GetStringSliceValueOrDefault("server.cors.exposedHeaders", []string{}), ","),
+			AllowCredentials: allowCredentials,
+			MaxAge:           int(c.GetDurationValueOrDefault("server.cors.maxAge", 0).Seconds()),
+		}), nil
+	})
+}
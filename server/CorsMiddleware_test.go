@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+func TestCorsRejectsWildcardOriginsWithCredentials(t *testing.T) {
+	provider := &mutableProvider{values: map[string]string{"server.cors.allowCredentials": "true"}}
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(provider)
+	config := builder.Build()
+
+	app := fiber.New()
+	app.Use(NewCorsMiddleware(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	allowedOrigin := func(origin string) string {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderOrigin, origin)
+		response, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response.Header.Get(fiber.HeaderAccessControlAllowOrigin)
+	}
+
+	if origin := allowedOrigin("https://evil.example"); origin != "" {
+		t.Errorf("expected credentials with a wildcard origin to be rejected, got %q", origin)
+	}
+
+	provider.values["server.cors.allowedOrigins"] = "https://app.example"
+	config.Reload()
+	if origin := allowedOrigin("https://app.example"); origin != "https://app.example" {
+		t.Errorf("expected the listed origin to be allowed, got %q", origin)
+	}
+
+	provider.values["server.cors.allowedOrigins"] = "*"
+	config.Reload()
+	if origin := allowedOrigin("https://evil.example"); origin != "" {
+		t.Errorf("expected the previous configuration to be kept, got %q", origin)
+	}
+	if origin := allowedOrigin("https://app.example"); origin != "https://app.example" {
+		t.Errorf("expected the previous configuration to be kept, got %q", origin)
+	}
+}
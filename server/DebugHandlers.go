@@ -0,0 +1,212 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/problems"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+var pprofHandlers = map[string]fiber.Handler{
+	"cmdline": adapt(pprof.Cmdline),
+	"profile": adapt(pprof.Profile),
+	"symbol":  adapt(pprof.Symbol),
+	"trace":   adapt(pprof.Trace),
+}
+
+type logLevelRequest struct {
+	Level     string `json:"level"`
+	Component string `json:"component,omitempty"`
+}
+
+// configureDebugHandlers mounts the diagnostic routes under /_system/debug, every route requires the token in
+// server.debug.adminToken and the routes are not found when no token is configured. Log levels set using
+// PUT /_system/debug/log/level override the configuration until they're cleared using DELETE
+func configureDebugHandlers(app *fiber.App, server *Server) {
+	config := server.GetConfiguration()
+	debugRoutes := app.Group("/_system/debug", func(c *fiber.Ctx) error {
+		token := config.GetStringValueOrDefault("server.debug.adminToken", "")
+		if token == "" {
+			return fiber.ErrNotFound
+		}
+
+		if subtle.ConstantTimeCompare([]byte(getAdminToken(c)), []byte(token)) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return problems.NewUnauthorizedError("a valid admin token is required")
+		}
+		return c.Next()
+	})
+
+	debugRoutes.Get("/pprof/:profile?", func(c *fiber.Ctx) error {
+		profile := c.Params("profile")
+		if profile == "" {
+			// the links on the index are relative so it must be served with a trailing slash
+			if !strings.HasSuffix(c.Path(), "/") {
+				return c.Redirect(c.Path()+"/", fiber.StatusFound)
+			}
+			return adapt(pprof.Index)(c)
+		}
+		if handler, found := pprofHandlers[profile]; found {
+			return handler(c)
+		}
+		if rpprof.Lookup(profile) == nil {
+			return fiber.ErrNotFound
+		}
+		return adapt(pprof.Handler(profile).ServeHTTP)(c)
+	})
+
+	debugRoutes.Get("/goroutines", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return rpprof.Lookup("goroutine").WriteTo(c, 2)
+	})
+
+	debugRoutes.Get("/runtime", func(c *fiber.Ctx) error {
+		memory := runtime.MemStats{}
+		runtime.ReadMemStats(&memory)
+
+		return c.JSON(fiber.Map{
+			"goVersion":    runtime.Version(),
+			"os":           runtime.GOOS,
+			"arch":         runtime.GOARCH,
+			"cpus":         runtime.NumCPU(),
+			"maxProcs":     runtime.GOMAXPROCS(0),
+			"goroutines":   runtime.NumGoroutine(),
+			"heapAlloc":    memory.HeapAlloc,
+			"heapInuse":    memory.HeapInuse,
+			"heapObjects":  memory.HeapObjects,
+			"totalAlloc":   memory.TotalAlloc,
+			"sys":          memory.Sys,
+			"numGC":        memory.NumGC,
+			"pauseTotalNs": memory.PauseTotalNs,
+		})
+	})
+
+	debugRoutes.Get("/buildinfo", func(c *fiber.Ctx) error {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return fiber.ErrNotFound
+		}
+		return c.JSON(info)
+	})
+
+	debugRoutes.Get("/services", func(c *fiber.Ctx) error {
+		services := []fiber.Map{}
+		for _, name := range server.serviceOrder {
+			services = append(services, fiber.Map{
+				"name": name,
+				"type": fmt.Sprintf("%T", *server.services[name]),
+			})
+		}
+		return c.JSON(services)
+	})
+
+	debugRoutes.Get("/configuration", func(c *fiber.Ctx) error {
+		providers := []fiber.Map{}
+		for _, provider := range config.Providers {
+			providers = append(providers, fiber.Map{
+				"name": provider.Name(),
+				"type": provider.Type(),
+			})
+		}
+
+		result := fiber.Map{"providers": providers}
+		if key := c.Query("key"); key != "" {
+			source := fiber.Map{"key": key, "found": false}
+			if provider, found := config.GetValueSource(key); found {
+				_, value := provider.TryGetValue(key)
+				if isSensitive(provider.Type(), key) {
+					value = "********"
+				}
+
+				source["found"] = true
+				source["provider"] = provider.Name()
+				source["providerType"] = provider.Type()
+				source["value"] = value
+			}
+			result["source"] = source
+		}
+
+		return c.JSON(result)
+	})
+
+	debugRoutes.Get("/log/level", func(c *fiber.Ctx) error {
+		return c.JSON(logLevelRequest{Level: log.GetLevel()})
+	})
+	debugRoutes.Put("/log/level", func(c *fiber.Ctx) error {
+		request := logLevelRequest{}
+		if err := c.BodyParser(&request); err != nil || request.Level == "" {
+			return problems.NewValidationError("the log level must be specified").
+				WithFieldError("level", "is required", "required")
+		}
+
+		var err error
+		if request.Component != "" {
+			err = log.SetComponentLevel(request.Component, strings.ToLower(request.Level))
+		} else {
+			err = log.SetLevel(strings.ToLower(request.Level))
+		}
+		if err != nil {
+			return problems.NewValidationError("the log level is not valid").
+				WithFieldError("level", "must be one of [debug info warn error dpanic panic fatal]", "oneof")
+		}
+
+		server.Logger().Warn("Log level changed", zap.String("component", request.Component), zap.String("level", request.Level))
+		return c.JSON(request)
+	})
+	debugRoutes.Delete("/log/level", func(c *fiber.Ctx) error {
+		component := c.Query("component")
+		if component != "" {
+			log.SetComponentLevel(component, "")
+		} else {
+			log.ClearLevel()
+		}
+
+		server.Logger().Warn("Log level override cleared", zap.String("component", component))
+		return c.JSON(logLevelRequest{Level: log.GetLevel()})
+	})
+}
+
+func getAdminToken(c *fiber.Ctx) string {
+	if token := c.Get("X-Admin-Token"); token != "" {
+		return token
+	}
+
+	authorization := c.Get(fiber.HeaderAuthorization)
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+func isSensitive(providerType string, key string) bool {
+	if providerType == "KubernetesSecret" {
+		return true
+	}
+
+	key = strings.ToLower(key)
+	for _, word := range []string{"secret", "password", "token", "key", "credential"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func adapt(handler func(w http.ResponseWriter, r *http.Request)) fiber.Handler {
+	fastHandler := fasthttpadaptor.NewFastHTTPHandlerFunc(handler)
+	return func(c *fiber.Ctx) error {
+		fastHandler(c.Context())
+		return nil
+	}
+}
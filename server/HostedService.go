@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// HostedService is started before the listeners are opened and stopped after they have been shutdown
+type HostedService interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// FaultReportingService is implemented by hosted services that can fail after they have been started,
+// faults are reported through the liveness health check
+type FaultReportingService interface {
+	HostedService
+	Faults() <-chan error
+}
+
+// Worker is a hosted service that runs a function in the background, such as a queue consumer or cron loop,
+// until the server stops. Returning an error or panicking before Stop has been called is reported as a fault.
+// The faults channel is closed once the function has returned and a stopped worker can be started again
+type Worker struct {
+	run    func(ctx context.Context) error
+	cancel context.CancelFunc
+	done   chan bool
+	faults chan error
+	mutex  *sync.Mutex
+}
+
+func NewWorker(run func(ctx context.Context) error) *Worker {
+	return &Worker{
+		run:    run,
+		faults: make(chan error, 1),
+		mutex:  &sync.Mutex{},
+	}
+}
+
+func (worker *Worker) Start(ctx context.Context) error {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+
+	if worker.cancel != nil {
+		return fmt.Errorf("worker has already been started")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+	faults := make(chan error, 1)
+	worker.cancel = cancel
+	worker.done = done
+	worker.faults = faults
+
+	go func() {
+		defer close(done)
+		defer close(faults)
+		defer func() {
+			if r := recover(); r != nil {
+				faults <- fmt.Errorf("panic in worker: %v", r)
+			}
+		}()
+
+		err := worker.run(ctx)
+		if ctx.Err() == nil {
+			if err == nil {
+				err = fmt.Errorf("worker stopped unexpectedly")
+			}
+			faults <- err
+		}
+	}()
+
+	return nil
+}
+
+func (worker *Worker) Stop(ctx context.Context) error {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+
+	if worker.cancel == nil {
+		return nil
+	}
+
+	worker.cancel()
+
+	select {
+	case <-worker.done:
+		// the faults channel has been closed by the worker so the state can be reset for the next start
+		worker.cancel = nil
+		worker.done = nil
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Faults returns the faults of the current run, the channel is closed when the run has finished
+func (worker *Worker) Faults() <-chan error {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+
+	return worker.faults
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/projectkeas/sdks-service/healthchecks"
+	"go.uber.org/zap"
+)
+
+const (
+	hostedServiceState_Pending  string = "Pending"
+	hostedServiceState_Running  string = "Running"
+	hostedServiceState_Faulted  string = "Faulted"
+	hostedServiceState_Stopped  string = "Stopped"
+	hostedServiceState_Starting string = "Starting"
+)
+
+type hostedServiceRegistration struct {
+	name      string
+	service   HostedService
+	dependsOn []string
+	state     string
+	fault     error
+}
+
+// hostedServiceHost starts the hosted services in dependency order and stops them in reverse, it doubles as
+// the liveness health check reporting any services that have faulted
+type hostedServiceHost struct {
+	registrations []*hostedServiceRegistration
+	started       []*hostedServiceRegistration
+	faults        chan error
+	mutex         *sync.RWMutex
+}
+
+func newHostedServiceHost() *hostedServiceHost {
+	return &hostedServiceHost{
+		faults: make(chan error, 1),
+		mutex:  &sync.RWMutex{},
+	}
+}
+
+func (host *hostedServiceHost) add(name string, service HostedService, dependsOn []string) {
+	host.registrations = append(host.registrations, &hostedServiceRegistration{
+		name:      name,
+		service:   service,
+		dependsOn: dependsOn,
+		state:     hostedServiceState_Pending,
+	})
+}
+
+func (host *hostedServiceHost) start(ctx context.Context, logger *zap.Logger) error {
+	ordered, err := host.order()
+	if err != nil {
+		return err
+	}
+
+	for _, registration := range ordered {
+		host.setState(registration, hostedServiceState_Starting, nil)
+		logger.Info(fmt.Sprintf("Starting hosted service: %s", registration.name))
+
+		err := registration.service.Start(ctx)
+		if err != nil {
+			host.setState(registration, hostedServiceState_Faulted, err)
+			return fmt.Errorf("unable to start hosted service '%s': %w", registration.name, err)
+		}
+
+		host.setState(registration, hostedServiceState_Running, nil)
+		host.started = append(host.started, registration)
+
+		if reporter, ok := registration.service.(FaultReportingService); ok {
+			go host.observeFaults(registration, reporter, logger)
+		}
+	}
+
+	return nil
+}
+
+func (host *hostedServiceHost) stop(timeout time.Duration, logger *zap.Logger) {
+	for i := len(host.started) - 1; i >= 0; i-- {
+		registration := host.started[i]
+		logger.Info(fmt.Sprintf("Stopping hosted service: %s", registration.name))
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := registration.service.Stop(ctx)
+		cancel()
+
+		if err != nil {
+			logger.Error("Unable to stop hosted service", zap.String("service", registration.name), zap.Error(err))
+		}
+
+		host.mutex.Lock()
+		if registration.state != hostedServiceState_Faulted {
+			registration.state = hostedServiceState_Stopped
+		}
+		host.mutex.Unlock()
+	}
+
+	host.started = nil
+}
+
+func (host *hostedServiceHost) observeFaults(registration *hostedServiceRegistration, reporter FaultReportingService, logger *zap.Logger) {
+	for err := range reporter.Faults() {
+		host.setState(registration, hostedServiceState_Faulted, err)
+		logger.Error("Hosted service faulted", zap.String("service", registration.name), zap.Error(err))
+
+		select {
+		case host.faults <- fmt.Errorf("hosted service '%s' faulted: %w", registration.name, err):
+		default:
+		}
+	}
+}
+
+func (host *hostedServiceHost) setState(registration *hostedServiceRegistration, state string, err error) {
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+
+	registration.state = state
+	registration.fault = err
+}
+
+// order sorts the registrations so that every service is started after the services it depends on
+func (host *hostedServiceHost) order() ([]*hostedServiceRegistration, error) {
+	registrations := map[string]*hostedServiceRegistration{}
+	for _, registration := range host.registrations {
+		registrations[registration.name] = registration
+	}
+
+	ordered := []*hostedServiceRegistration{}
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var visit func(registration *hostedServiceRegistration, path []string) error
+	visit = func(registration *hostedServiceRegistration, path []string) error {
+		if visited[registration.name] {
+			return nil
+		}
+		if visiting[registration.name] {
+			return fmt.Errorf("circular dependency between hosted services: %v", append(path, registration.name))
+		}
+
+		visiting[registration.name] = true
+		for _, name := range registration.dependsOn {
+			dependency, found := registrations[name]
+			if !found {
+				return fmt.Errorf("hosted service '%s' depends on unknown hosted service '%s'", registration.name, name)
+			}
+
+			err := visit(dependency, append(path, registration.name))
+			if err != nil {
+				return err
+			}
+		}
+		visiting[registration.name] = false
+		visited[registration.name] = true
+
+		ordered = append(ordered, registration)
+		return nil
+	}
+
+	for _, registration := range host.registrations {
+		err := visit(registration, []string{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+func (host *hostedServiceHost) Check() healthchecks.HealthCheckResult {
+	result := healthchecks.HealthCheckResult{
+		Duration: healthchecks.NewJsonTime(0 * time.Millisecond),
+		Name:     "HostedServicesCheck",
+		State:    healthchecks.HealthCheckState_Healthy,
+		Data:     map[string]string{},
+	}
+
+	host.mutex.RLock()
+	defer host.mutex.RUnlock()
+
+	for _, registration := range host.registrations {
+		result.Data[registration.name] = registration.state
+		if registration.state == hostedServiceState_Faulted {
+			result.State = healthchecks.HealthCheckState_Unhealthy
+			result.Data[registration.name] = fmt.Sprintf("%s: %s", registration.state, registration.fault.Error())
+		}
+	}
+
+	return result
+}
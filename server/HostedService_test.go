@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkerCanBeRestartedAfterStop(t *testing.T) {
+	worker := NewWorker(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := worker.Start(context.Background()); err != nil {
+			t.Fatalf("expected start %d to succeed, got %s", i+1, err)
+		}
+		faults := worker.Faults()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := worker.Stop(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("expected stop %d to succeed, got %s", i+1, err)
+		}
+
+		if _, open := <-faults; open {
+			t.Fatalf("expected the faults channel to be closed without a fault once the worker stopped")
+		}
+	}
+}
+
+func TestWorkerReportsFaults(t *testing.T) {
+	worker := NewWorker(func(ctx context.Context) error {
+		return errors.New("connection lost")
+	})
+
+	if err := worker.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	faults := []error{}
+	for err := range worker.Faults() {
+		faults = append(faults, err)
+	}
+	if len(faults) != 1 || faults[0].Error() != "connection lost" {
+		t.Errorf("expected a single fault before the channel was closed, got %v", faults)
+	}
+	if err := worker.Stop(context.Background()); err != nil {
+		t.Errorf("expected a faulted worker to stop, got %s", err)
+	}
+}
@@ -1,18 +1,39 @@
 package server
 
 import (
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/projectkeas/sdks-service/logger"
+	"github.com/projectkeas/sdks-service/problems"
+	"github.com/projectkeas/sdks-service/timing"
 	"go.uber.org/zap"
 )
 
+const HeaderServerTiming string = "Server-Timing"
+
 type LoggingConfig struct {
 	Fields             []string
 	ServerErrorMessage string
 	ClientErrorMessage string
 	SuccessMessage     string
+
+	// ExcludedPaths are path prefixes, such as probes, whose successful requests are only logged every
+	// SampleEvery requests. Failed requests are always logged
+	ExcludedPaths []string
+	// SampleEvery logs one in every N successful requests to the excluded paths, zero never logs them
+	SampleEvery int
+
+	// ServerTiming adds a Server-Timing header with the phases recorded by the timing package and the total
+	ServerTiming bool
+
+	// Payloads captures request and response bodies and headers when enabled, nil never captures them
+	Payloads *PayloadLogging
+
+	// Logger writes the request logs, defaults to the global "http" component logger
+	Logger *zap.Logger
 }
 
 func NewHttpLoggingMiddleware(config *LoggingConfig) fiber.Handler {
@@ -43,20 +64,36 @@ func NewHttpLoggingMiddleware(config *LoggingConfig) fiber.Handler {
 		config.SuccessMessage = "Request Processed Successfully"
 	}
 
-	return func(c *fiber.Ctx) (err error) {
-		var latency int64
+	var excludedRequests uint64
+	var httpLogger *zap.Logger
+	if config.Logger != nil {
+		httpLogger = config.Logger.Named("http")
+	}
 
+	return func(c *fiber.Ctx) (err error) {
 		start := time.Now()
 		chainErr := c.Next()
 		// we must call the error handler or we get a 200 status code in the logs -_-
 		if chainErr != nil {
 			c.App().ErrorHandler(c, chainErr)
 		}
-		latency = time.Since(start).Milliseconds()
+		latency := time.Since(start)
+		phases := timing.Get(c)
+
+		if config.ServerTiming {
+			c.Set(HeaderServerTiming, timing.Header(append(phases, timing.Phase{Name: "total", Duration: latency})))
+		}
 
 		fields := map[string]interface{}{}
 		statusCode := c.Response().StatusCode()
 
+		if statusCode < 400 && isExcludedPath(c.Path(), config.ExcludedPaths) {
+			count := atomic.AddUint64(&excludedRequests, 1)
+			if config.SampleEvery <= 0 || count%uint64(config.SampleEvery) != 0 {
+				return nil
+			}
+		}
+
 		fields["statusCode"] = statusCode
 		fields["ip"] = c.IP()
 
@@ -81,7 +118,7 @@ func NewHttpLoggingMiddleware(config *LoggingConfig) fiber.Handler {
 			case "ua":
 				fields["ua"] = c.Get(fiber.HeaderUserAgent)
 			case "latency":
-				fields["latency"] = latency
+				fields["latency"] = timing.Milliseconds(latency)
 			case "statusCode":
 				fields["statusCode"] = statusCode
 			case "queryParams":
@@ -102,8 +139,32 @@ func NewHttpLoggingMiddleware(config *LoggingConfig) fiber.Handler {
 			}
 		}
 
-		log := logger.Logger.WithOptions(zap.WithCaller(false)).With(zap.Any("http", fields))
+		if len(phases) > 0 {
+			timings := map[string]float64{}
+			for _, phase := range phases {
+				timings[phase.Name] = timing.Milliseconds(phase.Duration)
+			}
+			fields["timings"] = timings
+		}
+
+		if config.Payloads != nil {
+			config.Payloads.capture(c, fields)
+		}
 
+		requestLogger := httpLogger
+		if requestLogger == nil {
+			requestLogger = logger.Named("http")
+		}
+		log := requestLogger.WithOptions(zap.WithCaller(false)).With(zap.Any("http", fields))
+
+		if traceId := GetTraceId(c); traceId != "" {
+			log = log.With(logger.TraceId(traceId))
+		}
+
+		// middleware that renders errors itself, ie: idempotency, records them so they aren't lost
+		if chainErr == nil {
+			chainErr = problems.GetHandledError(c)
+		}
 		if chainErr != nil {
 			log = log.With(zap.Error(chainErr))
 		}
@@ -121,3 +182,12 @@ func NewHttpLoggingMiddleware(config *LoggingConfig) fiber.Handler {
 		return nil
 	}
 }
+
+func isExcludedPath(path string, excludedPaths []string) bool {
+	for _, excluded := range excludedPaths {
+		if strings.HasPrefix(path, excluded) {
+			return true
+		}
+	}
+	return false
+}
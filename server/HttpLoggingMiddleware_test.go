@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/idempotency"
+	"github.com/projectkeas/sdks-service/problems"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestErrorsRenderedByIdempotencyAreLogged(t *testing.T) {
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(configuration.NewInMemoryConfigurationProvider("test", map[string]string{}))
+	config := builder.Build()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			problem := problems.From(err)
+			return c.Status(problem.Status).JSON(problem)
+		},
+	})
+	app.Use(NewHttpLoggingMiddleware(&LoggingConfig{Logger: zap.New(core)}))
+	app.Use(idempotency.New(config, idempotency.Options{}))
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		return errors.New("database unavailable")
+	})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/orders", strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "key-1")
+	response, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != fiber.StatusInternalServerError {
+		t.Fatalf("expected a 500 response, got %d", response.StatusCode)
+	}
+
+	entries := logs.FilterLevelExact(zapcore.ErrorLevel).All()
+	if len(entries) != 1 {
+		t.Fatalf("expected the failed request to be logged as an error, got %d entries", len(entries))
+	}
+	if cause := entries[0].ContextMap()["error"]; cause != "database unavailable" {
+		t.Errorf("expected the cause of the error to be logged, got %v", cause)
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+// PayloadLoggingConfig controls the capture of request and response bodies and headers. Routes and
+// ExcludedRoutes are patterns where a trailing * matches the prefix and all other patterns use path.Match,
+// an empty Routes captures every route. An empty AllowedHeaders captures every header that is not denied
+type PayloadLoggingConfig struct {
+	Bodies         bool
+	Headers        bool
+	MaxBodyBytes   int
+	ContentTypes   []string
+	Routes         []string
+	ExcludedRoutes []string
+	AllowedHeaders []string
+	DeniedHeaders  []string
+}
+
+// PayloadLogging holds the payload settings so that they can be switched at runtime
+type PayloadLogging struct {
+	settings atomic.Value
+}
+
+type payloadSettings struct {
+	PayloadLoggingConfig
+	allowedHeaders map[string]bool
+	deniedHeaders  map[string]bool
+}
+
+var (
+	DefaultPayloadContentTypes = []string{
+		"application/json", "application/problem+json", "application/xml", "application/x-www-form-urlencoded", "text/*",
+	}
+	DefaultDeniedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Admin-Token"}
+)
+
+func NewPayloadLogging(config PayloadLoggingConfig) *PayloadLogging {
+	payloads := &PayloadLogging{}
+	payloads.Update(config)
+	return payloads
+}
+
+// Update replaces the payload settings used by subsequent requests
+func (payloads *PayloadLogging) Update(config PayloadLoggingConfig) {
+	settings := &payloadSettings{
+		PayloadLoggingConfig: config,
+		allowedHeaders:       map[string]bool{},
+		deniedHeaders:        map[string]bool{},
+	}
+	if settings.MaxBodyBytes <= 0 {
+		settings.MaxBodyBytes = 4096
+	}
+	for _, header := range config.AllowedHeaders {
+		settings.allowedHeaders[strings.ToLower(strings.TrimSpace(header))] = true
+	}
+	for _, header := range config.DeniedHeaders {
+		settings.deniedHeaders[strings.ToLower(strings.TrimSpace(header))] = true
+	}
+	payloads.settings.Store(settings)
+}
+
+func getPayloadLoggingConfig(config configuration.ConfigurationRoot) PayloadLoggingConfig {
+	return PayloadLoggingConfig{
+		Bodies:         config.GetBooleanValueOrDefault("log.http.body.enabled", false),
+		Headers:        config.GetBooleanValueOrDefault("log.http.headers.enabled", false),
+		MaxBodyBytes:   config.GetIntValueOrDefault("log.http.body.maxBytes", 4096),
+		ContentTypes:   config.GetStringSliceValueOrDefault("log.http.body.contentTypes", DefaultPayloadContentTypes),
+		Routes:         config.GetStringSliceValueOrDefault("log.http.payload.routes", []string{}),
+		ExcludedRoutes: config.GetStringSliceValueOrDefault("log.http.payload.excludedRoutes", []string{}),
+		AllowedHeaders: config.GetStringSliceValueOrDefault("log.http.headers.allow", []string{}),
+		DeniedHeaders:  config.GetStringSliceValueOrDefault("log.http.headers.deny", DefaultDeniedHeaders),
+	}
+}
+
+// capture adds the request and response payloads to the log fields, the redaction rules of the logger are
+// applied when the entry is written
+func (payloads *PayloadLogging) capture(c *fiber.Ctx, fields map[string]interface{}) {
+	settings := payloads.settings.Load().(*payloadSettings)
+	if !settings.Bodies && !settings.Headers {
+		return
+	}
+	if !settings.matchesRoute(c.Path()) {
+		return
+	}
+
+	request := map[string]interface{}{}
+	response := map[string]interface{}{}
+
+	if settings.Headers {
+		requestHeaders := map[string]string{}
+		c.Request().Header.VisitAll(func(key []byte, value []byte) {
+			settings.addHeader(requestHeaders, string(key), string(value))
+		})
+		request["headers"] = requestHeaders
+
+		responseHeaders := map[string]string{}
+		c.Response().Header.VisitAll(func(key []byte, value []byte) {
+			settings.addHeader(responseHeaders, string(key), string(value))
+		})
+		response["headers"] = responseHeaders
+	}
+
+	if settings.Bodies {
+		contentType := string(c.Request().Header.ContentType())
+		if body, err := c.Request().BodyUncompressed(); err == nil && len(body) > 0 && settings.isCapturedContentType(contentType) {
+			settings.addBody(request, body, contentType)
+		}
+
+		contentType = string(c.Response().Header.ContentType())
+		if !c.Response().IsBodyStream() && settings.isCapturedContentType(contentType) {
+			if body, err := c.Response().BodyUncompressed(); err == nil && len(body) > 0 {
+				settings.addBody(response, body, contentType)
+			}
+		}
+	}
+
+	if len(request) > 0 {
+		fields["request"] = request
+	}
+	if len(response) > 0 {
+		fields["response"] = response
+	}
+}
+
+func (settings *payloadSettings) matchesRoute(requestPath string) bool {
+	for _, pattern := range settings.ExcludedRoutes {
+		if matchesRoutePattern(pattern, requestPath) {
+			return false
+		}
+	}
+	if len(settings.Routes) == 0 {
+		return true
+	}
+	for _, pattern := range settings.Routes {
+		if matchesRoutePattern(pattern, requestPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRoutePattern(pattern string, requestPath string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(requestPath, strings.TrimSuffix(pattern, "*"))
+	}
+
+	matched, _ := path.Match(pattern, requestPath)
+	return matched
+}
+
+func (settings *payloadSettings) addHeader(headers map[string]string, key string, value string) {
+	name := strings.ToLower(key)
+	if settings.deniedHeaders[name] {
+		return
+	}
+	if len(settings.allowedHeaders) > 0 && !settings.allowedHeaders[name] {
+		return
+	}
+	headers[key] = value
+}
+
+func (settings *payloadSettings) isCapturedContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "" {
+		return false
+	}
+	for _, allowed := range settings.ContentTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if strings.HasSuffix(allowed, "*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+		if allowed == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// addBody logs JSON bodies as objects so that sensitive keys are redacted by the logger. JSON bodies over the
+// limit can't be redacted by key so only their size is logged, other bodies are redacted before they're
+// truncated so that values split by the limit are still removed
+func (settings *payloadSettings) addBody(payload map[string]interface{}, body []byte, contentType string) {
+	payload["size"] = len(body)
+	isJson := strings.Contains(contentType, "json")
+
+	if len(body) > settings.MaxBodyBytes {
+		payload["truncated"] = true
+		if !isJson {
+			// redact slightly more than the limit so that patterns which cross it are still matched
+			window := body[:minInt(len(body), settings.MaxBodyBytes+redactionMargin)]
+			payload["body"] = truncateString(log.RedactString(string(window)), settings.MaxBodyBytes)
+		}
+		return
+	}
+
+	if isJson && json.Valid(body) {
+		payload["body"] = json.RawMessage(append([]byte{}, body...))
+		return
+	}
+	payload["body"] = string(body)
+}
+
+// redactionMargin is the number of bytes beyond the limit that are redacted before a body is truncated
+const redactionMargin = 256
+
+// truncateString truncates the value to at most max bytes without splitting a multi-byte character
+func truncateString(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncatedBodiesAreRedacted(t *testing.T) {
+	payloads := NewPayloadLogging(PayloadLoggingConfig{Bodies: true, MaxBodyBytes: 32})
+	settings := payloads.settings.Load().(*payloadSettings)
+
+	payload := map[string]interface{}{}
+	settings.addBody(payload, []byte(`{"password":"hunter22","padding":"`+strings.Repeat("x", 64)+`"}`), "application/json")
+	if _, found := payload["body"]; found {
+		t.Errorf("expected truncated JSON bodies to only log their size, got %v", payload["body"])
+	}
+	if payload["truncated"] != true {
+		t.Errorf("expected the body to be marked as truncated")
+	}
+
+	payload = map[string]interface{}{}
+	settings.addBody(payload, []byte("token=secret-value&"+strings.Repeat("x", 64)), "application/x-www-form-urlencoded")
+	if body := payload["body"].(string); strings.Contains(body, "secret-value") {
+		t.Errorf("expected the truncated body to be redacted, got %q", body)
+	}
+}
+
+func TestTruncateStringKeepsCharactersWhole(t *testing.T) {
+	truncated := truncateString("ab€", 3)
+	if truncated != "ab" || !utf8.ValidString(truncated) {
+		t.Errorf("expected the multi-byte character to be dropped, got %q", truncated)
+	}
+}
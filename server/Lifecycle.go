@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/healthchecks"
+	"go.uber.org/zap"
+)
+
+// lifecycleHealthCheck reports the server as unready once shutdown has begun so that Kubernetes stops
+// routing traffic to the pod before the listeners are closed
+type lifecycleHealthCheck struct {
+	stopping int32
+}
+
+func (check *lifecycleHealthCheck) Check() healthchecks.HealthCheckResult {
+	result := healthchecks.HealthCheckResult{
+		Duration: healthchecks.NewJsonTime(0 * time.Millisecond),
+		Name:     "LifecycleCheck",
+		State:    healthchecks.HealthCheckState_Healthy,
+		Data: map[string]string{
+			"state": "Running",
+		},
+	}
+
+	if atomic.LoadInt32(&check.stopping) == 1 {
+		result.State = healthchecks.HealthCheckState_Unhealthy
+		result.Data["state"] = "Stopping"
+	}
+
+	return result
+}
+
+func (check *lifecycleHealthCheck) markStopping() {
+	atomic.StoreInt32(&check.stopping, 1)
+}
+
+func runJob(ctx context.Context, server *Server, job JobFunc, result chan<- error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result <- fmt.Errorf("panic in job: %v", r)
+		}
+	}()
+
+	result <- job(ctx, server)
+}
+
+func shutdownWithTimeout(app *fiber.App, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- app.Shutdown()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("listener did not shutdown within %s", timeout)
+	}
+}
+
+// disposeServices disposes of the registered services in the reverse order to which they were registered
+// so that services are disposed of before the services they depend upon
+func disposeServices(server *Server, timeout time.Duration) {
+	for i := len(server.serviceOrder) - 1; i >= 0; i-- {
+		name := server.serviceOrder[i]
+		disposable, castSuccessful := (*server.services[name]).(Disposable)
+		if !castSuccessful {
+			continue
+		}
+
+		server.Logger().Info(fmt.Sprintf("Deregistering service: %s", name))
+		err := disposeWithTimeout(disposable, timeout)
+		if err != nil {
+			server.Logger().Error("Unable to dispose service", zap.String("service", name), zap.Error(err))
+		}
+	}
+}
+
+func disposeWithTimeout(disposable Disposable, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("panic whilst disposing service: %v", r)
+			}
+		}()
+
+		disposable.Dispose()
+		result <- nil
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("service was not disposed within %s", timeout)
+	}
+}
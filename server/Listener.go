@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap"
+)
+
+func applyServerConfiguration(fiberConfig *fiber.Config, config *configuration.ConfigurationRoot) {
+	fiberConfig.ReadTimeout = config.GetDurationValueOrDefault("server.timeouts.read", 0)
+	fiberConfig.WriteTimeout = config.GetDurationValueOrDefault("server.timeouts.write", 0)
+	fiberConfig.IdleTimeout = config.GetDurationValueOrDefault("server.timeouts.idle", 0)
+	fiberConfig.BodyLimit = config.GetIntValueOrDefault("server.bodyLimit", fiber.DefaultBodyLimit)
+	fiberConfig.Concurrency = config.GetIntValueOrDefault("server.concurrency", fiber.DefaultConcurrency)
+	fiberConfig.Prefork = config.GetBooleanValueOrDefault("server.prefork", false)
+
+	// trust the proxy header so that c.IP() reports the client rather than the ingress controller
+	fiberConfig.ProxyHeader = config.GetStringValueOrDefault("server.proxy.header", "")
+	fiberConfig.TrustedProxies = config.GetStringSliceValueOrDefault("server.proxy.trustedProxies", []string{})
+	fiberConfig.EnableTrustedProxyCheck = len(fiberConfig.TrustedProxies) > 0
+}
+
+func getListenAddress(config *configuration.ConfigurationRoot) string {
+	return net.JoinHostPort(config.GetStringValueOrDefault("server.address", ""), config.GetStringValueOrDefault("server.port", "5000"))
+}
+
+func listen(app *fiber.App, address string, tlsConfig *tls.Config, logger *zap.Logger) error {
+	if tlsConfig == nil {
+		logger.Info("Application listening", zap.String("address", address))
+		return app.Listen(address)
+	}
+
+	ln, err := net.Listen(app.Config().Network, address)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Application listening", zap.String("address", address), zap.Bool("tls", true))
+	return app.Listener(tls.NewListener(ln, tlsConfig))
+}
+
+func getTLSConfig(server *Server, config *configuration.ConfigurationRoot) (*tls.Config, error) {
+	var reloader *certificateReloader
+
+	certFile := config.GetStringValueOrDefault("server.tls.certFile", "")
+	keyFile := config.GetStringValueOrDefault("server.tls.keyFile", "")
+
+	if server.tlsSecret != "" {
+		reloader = newSecretCertificateReloader(config, server.tlsSecret, server.Logger())
+	} else if certFile != "" && keyFile != "" {
+		r, err := newFileCertificateReloader(certFile, keyFile, config.GetDurationValueOrDefault("server.tls.reloadInterval", time.Minute), server.Logger())
+		if err != nil {
+			return nil, err
+		}
+		reloader = r
+	} else {
+		return nil, nil
+	}
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
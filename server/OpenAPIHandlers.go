@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/buildinfo"
+	"github.com/projectkeas/sdks-service/openapi"
+)
+
+const swaggerUI string = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>%s</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@4/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: '/_system/openapi.json', dom_id: '#swagger-ui' });
+		};
+	</script>
+</body>
+</html>`
+
+func configureOpenAPIHandlers(app *fiber.App, server *Server, development bool) {
+	config := server.GetConfiguration()
+	if !config.GetBooleanValueOrDefault("server.openapi.enabled", true) {
+		return
+	}
+
+	app.Get("/_system/openapi.json", func(c *fiber.Ctx) error {
+		if server.routes == nil {
+			return fiber.ErrNotFound
+		}
+
+		info := openapi.Info{
+			Title:       config.GetStringValueOrDefault("server.openapi.title", server.AppName),
+			Description: config.GetStringValueOrDefault("server.openapi.description", ""),
+			Version:     config.GetStringValueOrDefault("server.openapi.version", openAPIVersion()),
+		}
+		return c.JSON(openapi.Generate(info, server.routes, server.openapi))
+	})
+
+	if development && config.GetBooleanValueOrDefault("server.openapi.swaggerUI", true) {
+		app.Get("/_system/swagger", func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+			return c.SendString(fmt.Sprintf(swaggerUI, server.AppName))
+		})
+	}
+}
+
+func openAPIVersion() string {
+	if version := buildinfo.Get().Version; version != "" {
+		return version
+	}
+	return "1.0.0"
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/projectkeas/sdks-service/authentication"
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+type rateLimitSettings struct {
+	max        int
+	expiration time.Duration
+	keyBy      string
+	header     string
+}
+
+// NewRateLimitMiddleware limits the number of requests per key within the configured window. Requests
+// are keyed by the client ip by default, or by header (server.ratelimit.header) or principal. The limiter
+// is only rebuilt when the limits change and the counters are kept across changes.
+//
+// Behind an ingress, configure server.proxy.header and server.proxy.trustedProxies so that the ip is
+// read from the proxy rather than keying on headers such as X-Forwarded-For which clients can set
+// themselves. Only key by header when it's set by a trusted proxy.
+//
+// Keying by principal (or apikey) uses the principal authenticated by WithAuthentication, register it
+// before WithRateLimiting otherwise every request falls back to the ip
+func NewRateLimitMiddleware(config *configuration.ConfigurationRoot) fiber.Handler {
+	storage := newMemoryStorage()
+	current := atomic.Value{}
+	keyBy := atomic.Value{}
+	var previous *rateLimitSettings
+
+	keyGenerator := func(ctx *fiber.Ctx) string {
+		return keyBy.Load().(func(*fiber.Ctx) string)(ctx)
+	}
+
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		settings := rateLimitSettings{
+			max:        c.GetIntValueOrDefault("server.ratelimit.max", 100),
+			expiration: c.GetDurationValueOrDefault("server.ratelimit.expiration", time.Minute),
+			keyBy:      strings.ToLower(c.GetStringValueOrDefault("server.ratelimit.keyBy", "ip")),
+			header:     c.GetStringValueOrDefault("server.ratelimit.header", ""),
+		}
+		if previous != nil && *previous == settings {
+			return
+		}
+		keyBy.Store(newRateLimitKeyGenerator(settings))
+
+		// fiber starts a goroutine for every limiter that is never stopped so it's only rebuilt when the
+		// limits change, the counters are held in the shared storage
+		if previous == nil || previous.max != settings.max || previous.expiration != settings.expiration {
+			current.Store(newRateLimiter(settings, storage, keyGenerator))
+		}
+		previous = &settings
+	})
+
+	return func(c *fiber.Ctx) error {
+		return current.Load().(fiber.Handler)(c)
+	}
+}
+
+func newRateLimiter(settings rateLimitSettings, storage fiber.Storage, keyGenerator func(*fiber.Ctx) string) fiber.Handler {
+	if settings.max <= 0 {
+		return func(ctx *fiber.Ctx) error {
+			return ctx.Next()
+		}
+	}
+
+	return limiter.New(limiter.Config{
+		Next:         isSystemRoute,
+		Max:          settings.max,
+		Expiration:   settings.expiration,
+		KeyGenerator: keyGenerator,
+		Storage:      storage,
+		LimitReached: func(ctx *fiber.Ctx) error {
+			return fiber.ErrTooManyRequests
+		},
+	})
+}
+
+func newRateLimitKeyGenerator(settings rateLimitSettings) func(*fiber.Ctx) string {
+	keyByIp := func(ctx *fiber.Ctx) string {
+		return "ip:" + ctx.IP()
+	}
+
+	switch settings.keyBy {
+	case "header":
+		if settings.header == "" {
+			break
+		}
+		return func(ctx *fiber.Ctx) string {
+			value := ctx.Get(settings.header)
+			if value == "" {
+				return keyByIp(ctx)
+			}
+			return "header:" + value
+		}
+	case "principal", "apikey":
+		// the credentials are only trusted once they've been validated, keying on the raw value would
+		// allow clients to send a different key with every request to get a new bucket
+		return func(ctx *fiber.Ctx) string {
+			principal, found := authentication.GetPrincipal(ctx)
+			if !found || principal == nil {
+				return keyByIp(ctx)
+			}
+			return "principal:" + principal.Type + ":" + principal.Id
+		}
+	}
+
+	return keyByIp
+}
+
+// memoryStorage holds the rate limit counters, expired entries are removed as new entries are set rather
+// than by a background goroutine so that it doesn't need to be closed
+type memoryStorage struct {
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	mutex     *sync.Mutex
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		entries:   map[string]memoryEntry{},
+		lastSweep: time.Now(),
+		mutex:     &sync.Mutex{},
+	}
+}
+
+func (storage *memoryStorage) Get(key string) ([]byte, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	entry, found := storage.entries[key]
+	if !found || (!entry.expires.IsZero() && time.Now().After(entry.expires)) {
+		return nil, nil
+	}
+	return entry.value, nil
+}
+
+func (storage *memoryStorage) Set(key string, value []byte, expiration time.Duration) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(storage.lastSweep) > time.Minute {
+		for k, entry := range storage.entries {
+			if !entry.expires.IsZero() && now.After(entry.expires) {
+				delete(storage.entries, k)
+			}
+		}
+		storage.lastSweep = now
+	}
+
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		entry.expires = now.Add(expiration)
+	}
+	storage.entries[key] = entry
+	return nil
+}
+
+func (storage *memoryStorage) Delete(key string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	delete(storage.entries, key)
+	return nil
+}
+
+func (storage *memoryStorage) Reset() error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.entries = map[string]memoryEntry{}
+	return nil
+}
+
+func (storage *memoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+type mutableProvider struct {
+	values map[string]string
+}
+
+func (provider *mutableProvider) Name() string {
+	return "test"
+}
+
+func (provider *mutableProvider) Type() string {
+	return "InMemory"
+}
+
+func (provider *mutableProvider) TryGetValue(key string) (bool, string) {
+	value, found := provider.values[key]
+	return found, value
+}
+
+func newRateLimitedApp(t *testing.T, values map[string]string) (*fiber.App, *mutableProvider, *configuration.ConfigurationRoot) {
+	provider := &mutableProvider{values: values}
+	builder := configuration.NewConfigurationBuilder(false)
+	builder.AddConfigurationProvider(provider)
+	config := builder.Build()
+
+	app := fiber.New()
+	app.Use(NewRateLimitMiddleware(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	return app, provider, config
+}
+
+func sendRequests(t *testing.T, app *fiber.App, count int, headers func(i int) map[string]string) int {
+	status := 0
+	for i := 0; i < count; i++ {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		for key, value := range headers(i) {
+			req.Header.Set(key, value)
+		}
+		response, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status = response.StatusCode
+	}
+	return status
+}
+
+func TestRateLimitIgnoresClientSuppliedKeys(t *testing.T) {
+	cases := map[string]func(i int) map[string]string{
+		"ip": func(i int) map[string]string {
+			return map[string]string{fiber.HeaderXForwardedFor: "10.0.0." + strconv.Itoa(i)}
+		},
+		"header": func(i int) map[string]string {
+			return map[string]string{fiber.HeaderXForwardedFor: "10.0.0." + strconv.Itoa(i)}
+		},
+		"apikey": func(i int) map[string]string {
+			return map[string]string{fiber.HeaderAuthorization: "ApiKey random-" + strconv.Itoa(i), "X-API-Key": strconv.Itoa(i)}
+		},
+	}
+
+	for keyBy, headers := range cases {
+		app, _, _ := newRateLimitedApp(t, map[string]string{
+			"server.ratelimit.max":   "2",
+			"server.ratelimit.keyBy": keyBy,
+		})
+
+		if status := sendRequests(t, app, 3, headers); status != fiber.StatusTooManyRequests {
+			t.Errorf("expected client supplied values not to reset the limit when keyed by %s, got %d", keyBy, status)
+		}
+	}
+}
+
+func TestRateLimitKeepsCountersAcrossReloads(t *testing.T) {
+	app, provider, config := newRateLimitedApp(t, map[string]string{"server.ratelimit.max": "2"})
+	noHeaders := func(i int) map[string]string { return nil }
+
+	sendRequests(t, app, 2, noHeaders)
+
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		provider.values["unrelated"] = strconv.Itoa(i)
+		config.Reload()
+	}
+	provider.values["server.ratelimit.keyBy"] = "principal"
+	config.Reload()
+
+	time.Sleep(10 * time.Millisecond)
+	if leaked := runtime.NumGoroutine() - goroutines; leaked > 0 {
+		t.Errorf("expected reloading the configuration not to start goroutines, got %d", leaked)
+	}
+
+	provider.values["server.ratelimit.expiration"] = "2m"
+	config.Reload()
+
+	if status := sendRequests(t, app, 1, noHeaders); status != fiber.StatusTooManyRequests {
+		t.Errorf("expected the counters to be kept when the configuration changes, got %d", status)
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+	"go.uber.org/zap"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+type middlewareFactory func(server *Server) fiber.Handler
+
+// newReloadableMiddleware rebuilds the underlying handler every time the configuration changes so that
+// settings sourced from ConfigMaps/Secrets are applied without restarting the pod. When the factory returns
+// an error the previous handler is kept, or the middleware is skipped if there is no previous handler
+func newReloadableMiddleware(config *configuration.ConfigurationRoot, factory func(config configuration.ConfigurationRoot) (fiber.Handler, error)) fiber.Handler {
+	current := atomic.Value{}
+
+	config.RegisterChangeNotificationHandler(func(c configuration.ConfigurationRoot) {
+		handler, err := factory(c)
+		if err != nil {
+			log.Logger.Error("Unable to apply the middleware configuration, the previous configuration remains in place", zap.Error(err))
+			if current.Load() == nil {
+				current.Store(fiber.Handler(func(c *fiber.Ctx) error {
+					return c.Next()
+				}))
+			}
+			return
+		}
+		current.Store(handler)
+	})
+
+	return func(c *fiber.Ctx) error {
+		return current.Load().(fiber.Handler)(c)
+	}
+}
+
+func isSystemRoute(c *fiber.Ctx) bool {
+	return strings.HasPrefix(c.Path(), "/_system")
+}
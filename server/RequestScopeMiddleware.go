@@ -0,0 +1,46 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/container"
+)
+
+const (
+	requestScopeKey string = "keas.requestScope"
+	serverKey       string = "keas.server"
+)
+
+// GetRequestScope returns the container scope for the current request, request scoped services are
+// disposed of once the request has completed. An error is returned when the request wasn't served by a
+// Server, ie: the app was created without NewApp
+func GetRequestScope(c *fiber.Ctx) (*container.Scope, error) {
+	scope, found := c.Locals(requestScopeKey).(*container.Scope)
+	if !found {
+		server, _ := c.Locals(serverKey).(*Server)
+		if server == nil || server.container == nil {
+			return nil, fmt.Errorf("the request has no scope, the request scope middleware has not been registered")
+		}
+		scope = server.container.NewScope()
+		c.Locals(requestScopeKey, scope)
+	}
+
+	return scope, nil
+}
+
+// newRequestScopeMiddleware only creates the scope when it's first requested so that requests which don't
+// use the container don't pay for it
+func newRequestScopeMiddleware(server *Server) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(serverKey, server)
+
+		defer func() {
+			if scope, found := c.Locals(requestScopeKey).(*container.Scope); found {
+				scope.Dispose()
+			}
+		}()
+
+		return c.Next()
+	}
+}
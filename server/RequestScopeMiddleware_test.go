@@ -0,0 +1,105 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/container"
+)
+
+type requestService struct {
+	id       int
+	disposed bool
+}
+
+func (service *requestService) Dispose() {
+	service.disposed = true
+}
+
+func TestRequestScope(t *testing.T) {
+	created := []*requestService{}
+	builder := container.NewBuilder()
+	container.Register[*requestService](builder, container.Lifetime_Request, func() *requestService {
+		service := &requestService{id: len(created)}
+		created = append(created, service)
+		return service
+	})
+	serviceContainer, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected int
+	}{
+		{name: "scope is not created until requested", path: "/unused", status: fiber.StatusOK, expected: 0},
+		{name: "services are shared within the request", path: "/twice", status: fiber.StatusOK, expected: 1},
+		{name: "scope is disposed when the handler fails", path: "/fails", status: fiber.StatusInternalServerError, expected: 1},
+	}
+
+	app := fiber.New()
+	app.Use(newRequestScopeMiddleware(&Server{container: serviceContainer}))
+	app.Get("/unused", func(c *fiber.Ctx) error {
+		return nil
+	})
+	app.Get("/twice", func(c *fiber.Ctx) error {
+		for i := 0; i < 2; i++ {
+			scope, err := GetRequestScope(c)
+			if err != nil {
+				return err
+			}
+			container.MustResolve[*requestService](scope)
+		}
+		return nil
+	})
+	app.Get("/fails", func(c *fiber.Ctx) error {
+		scope, err := GetRequestScope(c)
+		if err != nil {
+			return err
+		}
+		container.MustResolve[*requestService](scope)
+		return errors.New("failed")
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			created = []*requestService{}
+
+			response, err := app.Test(httptest.NewRequest(fiber.MethodGet, test.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != test.status {
+				t.Errorf("expected status %d, got %d", test.status, response.StatusCode)
+			}
+			if len(created) != test.expected {
+				t.Fatalf("expected %d services to be created, got %d", test.expected, len(created))
+			}
+			for _, service := range created {
+				if !service.disposed {
+					t.Errorf("expected the request scoped service to be disposed after the request")
+				}
+			}
+		})
+	}
+}
+
+func TestGetRequestScopeWithoutServer(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		scope, err := GetRequestScope(c)
+		if err == nil || scope != nil {
+			t.Errorf("expected an error without the request scope middleware, got %v", scope)
+		}
+		return nil
+	})
+
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+)
+
+// NewSecurityHeadersMiddleware adds helmet style security headers to every response, each header can be
+// disabled by configuring an empty value for its key
+func NewSecurityHeadersMiddleware(config *configuration.ConfigurationRoot) fiber.Handler {
+	return newReloadableMiddleware(config, func(c configuration.ConfigurationRoot) (fiber.Handler, error) {
+		headers := map[string]string{
+			fiber.HeaderContentSecurityPolicy:         c.GetStringValueOrDefault("server.security.contentSecurityPolicy", "default-src 'self';base-uri 'self';frame-ancestors 'self';object-src 'none'"),
+			"Cross-Origin-Opener-Policy":              c.GetStringValueOrDefault("server.security.crossOriginOpenerPolicy", "same-origin"),
+			"Cross-Origin-Resource-Policy":            c.GetStringValueOrDefault("server.security.crossOriginResourcePolicy", "same-origin"),
+			fiber.HeaderPermissionsPolicy:             c.GetStringValueOrDefault("server.security.permissionsPolicy", ""),
+			fiber.HeaderReferrerPolicy:                c.GetStringValueOrDefault("server.security.referrerPolicy", "no-referrer"),
+			fiber.HeaderXContentTypeOptions:           c.GetStringValueOrDefault("server.security.contentTypeOptions", "nosniff"),
+			fiber.HeaderXDNSPrefetchControl:           c.GetStringValueOrDefault("server.security.dnsPrefetchControl", "off"),
+			fiber.HeaderXDownloadOptions:              c.GetStringValueOrDefault("server.security.downloadOptions", "noopen"),
+			fiber.HeaderXFrameOptions:                 c.GetStringValueOrDefault("server.security.frameOptions", "SAMEORIGIN"),
+			fiber.HeaderXPermittedCrossDomainPolicies: c.GetStringValueOrDefault("server.security.permittedCrossDomainPolicies", "none"),
+			fiber.HeaderXXSSProtection:                c.GetStringValueOrDefault("server.security.xssProtection", "0"),
+		}
+
+		hstsMaxAge := int(c.GetDurationValueOrDefault("server.security.hsts.maxAge", 0).Seconds())
+		if hstsMaxAge > 0 {
+			hsts := fmt.Sprintf("max-age=%d", hstsMaxAge)
+			if c.GetBooleanValueOrDefault("server.security.hsts.includeSubdomains", true) {
+				hsts += "; includeSubDomains"
+			}
+			if c.GetBooleanValueOrDefault("server.security.hsts.preload", false) {
+				hsts += "; preload"
+			}
+			headers[fiber.HeaderStrictTransportSecurity] = hsts
+		}
+
+		for key, value := range headers {
+			if value == "" {
+				delete(headers, key)
+			}
+		}
+
+		return func(ctx *fiber.Ctx) error {
+			for key, value := range headers {
+				ctx.Set(key, value)
+			}
+			return ctx.Next()
+		}, nil
+	})
+}
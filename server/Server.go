@@ -1,17 +1,32 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 
+	"github.com/projectkeas/sdks-service/audit"
+	"github.com/projectkeas/sdks-service/buildinfo"
 	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/container"
 	"github.com/projectkeas/sdks-service/healthchecks"
 	log "github.com/projectkeas/sdks-service/logger"
+	"github.com/projectkeas/sdks-service/opa"
+	"github.com/projectkeas/sdks-service/openapi"
+	"github.com/projectkeas/sdks-service/problems"
+	"go.uber.org/zap"
 )
 
 type FiberAppFunc func(app *fiber.App, server *Server)
@@ -19,38 +34,85 @@ type FiberAppFunc func(app *fiber.App, server *Server)
 type Server struct {
 	AppName string
 
-	handlerConfig FiberAppFunc
-	services      map[string]*interface{}
+	handlerConfig       FiberAppFunc
+	systemHandlerConfig []FiberAppFunc
+	middleware          []middlewareFactory
+	errorMappers        []problems.Mapper
+	tlsSecret           string
+	lifecycle           *lifecycleHealthCheck
+	hostedServices      *hostedServiceHost
+	services            map[string]*interface{}
+	serviceOrder        []string
+	container           *container.Container
+	openapi             *openapi.Registry
+	routes              *fiber.App
+	logger              *zap.Logger
+	audit               *audit.Service
 }
 
-func newServer(appName string, handlerConfig FiberAppFunc) Server {
+func newServer(appName string, handlerConfig FiberAppFunc, middleware []middlewareFactory) Server {
 	server := Server{
-		AppName:       appName,
-		handlerConfig: handlerConfig,
-		services:      map[string]*interface{}{},
+		AppName:        appName,
+		handlerConfig:  handlerConfig,
+		middleware:     middleware,
+		lifecycle:      &lifecycleHealthCheck{},
+		hostedServices: newHostedServiceHost(),
+		services:       map[string]*interface{}{},
+		openapi:        openapi.NewRegistry(),
 	}
 	return server
 }
 
 func (server *Server) GetConfiguration() *configuration.ConfigurationRoot {
-	svc, _ := server.GetService(configuration.SERVICE_NAME)
-	temp := (*svc).(*configuration.ConfigurationRoot)
-	return temp
+	return container.MustResolve[*configuration.ConfigurationRoot](server.container)
 }
 
 func (server *Server) GetHealthCheckRunner() *healthchecks.HealthCheckRunner {
-	svc, _ := server.GetService(healthchecks.SERVICE_NAME)
-	temp := (*svc).(healthchecks.HealthCheckRunner)
-	return &temp
+	return container.MustResolve[*healthchecks.HealthCheckRunner](server.container)
+}
+
+func (server *Server) GetOPAService() *opa.OPAService {
+	return container.MustResolve[*opa.OPAService](server.container)
+}
+
+// GetAuditService returns the service registered by WithAudit, nil when auditing is not enabled
+func (server *Server) GetAuditService() *audit.Service {
+	return server.audit
+}
+
+func (server *Server) Container() *container.Container {
+	return server.container
+}
+
+// Logger returns the logger provided by WithLogger, or the global logger when none was provided
+func (server *Server) Logger() *zap.Logger {
+	if server.logger != nil {
+		return server.logger
+	}
+	return log.Logger
+}
+
+// OpenAPI returns the registry used to document the request and response types of routes
+func (server *Server) OpenAPI() *openapi.Registry {
+	return server.openapi
 }
 
 func (server *Server) RegisterService(name string, service interface{}) {
 	_, castSuccessful := (service).(Disposable)
 	if castSuccessful {
-		log.Logger.Info(fmt.Sprintf("Registering service: %s", name))
+		server.Logger().Info(fmt.Sprintf("Registering service: %s", name))
+	}
+
+	// re-registering a service moves it to the end so that it's disposed of before its dependencies
+	for i, key := range server.serviceOrder {
+		if key == name {
+			server.serviceOrder = append(server.serviceOrder[:i], server.serviceOrder[i+1:]...)
+			break
+		}
 	}
 
 	server.services[name] = &service
+	server.serviceOrder = append(server.serviceOrder, name)
 }
 
 func (server *Server) GetService(name string) (*interface{}, error) {
@@ -63,6 +125,23 @@ func (server *Server) GetService(name string) (*interface{}, error) {
 	return nil, fmt.Errorf("unable to locate service '%s'", name)
 }
 
+// Dispose disposes of the services created by the container followed by the registered services, it's
+// called automatically when the server stops
+func (server *Server) Dispose() {
+	server.container.Dispose()
+	disposeServices(server, server.GetConfiguration().GetDurationValueOrDefault("server.shutdown.serviceTimeout", 10*time.Second))
+}
+
+// JobFunc is run by RunJob once the hosted services have started, the context is cancelled when a shutdown
+// signal is received
+type JobFunc func(ctx context.Context, server *Server) error
+
+type runOptions struct {
+	development         bool
+	serveBusinessRoutes bool
+	job                 JobFunc
+}
+
 func (server *Server) Run() {
 	runServer(server, false)
 }
@@ -71,42 +150,315 @@ func (server *Server) RunDevelopment() {
 	runServer(server, true)
 }
 
+// RunWorker starts the services and hosted services without serving the business routes, the system routes
+// are still served when server.management.port is configured. It blocks until the context is cancelled, a
+// shutdown signal is received or a hosted service faults
+func (server *Server) RunWorker(ctx context.Context) error {
+	return run(ctx, server, runOptions{})
+}
+
+// RunJob runs the job to completion in the same way as RunWorker and returns the exit code for the process:
+//
+//	os.Exit(host.RunJob(context.Background(), job))
+func (server *Server) RunJob(ctx context.Context, job JobFunc) int {
+	err := run(ctx, server, runOptions{job: job})
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
+
 func runServer(server *Server, development bool) {
-	app := fiber.New(fiber.Config{
+	err := run(context.Background(), server, runOptions{
+		development:         development,
+		serveBusinessRoutes: true,
+	})
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, server *Server, options runOptions) error {
+	config := server.GetConfiguration()
+	listeners := map[*fiber.App]func() error{}
+
+	var app *fiber.App
+	if options.serveBusinessRoutes {
+		businessApp, tlsConfig, err := newBusinessApp(server, options.development)
+		if err != nil {
+			server.Logger().Error("Unable to configure TLS", zap.Error(err))
+			return err
+		}
+
+		app = businessApp
+		listeners[app] = func() error {
+			return listen(app, getListenAddress(config), tlsConfig, server.Logger())
+		}
+	}
+
+	// System routes are served from a separate listener when a management port has been configured so
+	// that they are not exposed through the public ingress
+	managementApp := app
+	managementPort := config.GetStringValueOrDefault("server.management.port", "")
+	if managementPort != "" {
+		managementApp = newManagementApp(server, options.development)
+
+		address := net.JoinHostPort(config.GetStringValueOrDefault("server.management.address", config.GetStringValueOrDefault("server.address", "")), managementPort)
+		listeners[managementApp] = func() error {
+			return listen(managementApp, address, nil, server.Logger())
+		}
+	}
+
+	if managementApp != nil {
+		configureSystemHandlers(managementApp, server, options.development)
+	}
+
+	if app != nil && server.handlerConfig != nil {
+		server.handlerConfig(app, server)
+	}
+	server.routes = app
+
+	serviceTimeout := config.GetDurationValueOrDefault("server.shutdown.serviceTimeout", 10*time.Second)
+	stopServices := func() {
+		server.hostedServices.stop(serviceTimeout, server.Logger())
+		server.Dispose()
+		server.Logger().Sync()
+	}
+
+	// hosted services are started before the listeners open so that they're available to the handlers
+	lifetime, cancelLifetime := context.WithCancel(context.Background())
+	defer cancelLifetime()
+
+	err := server.hostedServices.start(lifetime, server.Logger())
+	if err != nil {
+		server.Logger().Error("Unable to start hosted services", zap.Error(err))
+		stopServices()
+		return err
+	}
+
+	// Handle graceful shutdown by proxying with a channel
+	shutdownChannel := make(chan os.Signal, 1)
+	signal.Notify(shutdownChannel, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(shutdownChannel)
+
+	// workers have no other way of reporting a failure so they exit on failure by default
+	var hostedServiceFaults <-chan error
+	if config.GetBooleanValueOrDefault("server.hostedServices.exitOnFailure", !options.serveBusinessRoutes) {
+		hostedServiceFaults = server.hostedServices.faults
+	}
+
+	server.Logger().Info("Application starting...")
+
+	// if any of the listeners fail then the remaining listeners are stopped so that the pod is restarted
+	wg := &sync.WaitGroup{}
+	listenerErrors := make(chan error, len(listeners))
+	for _, start := range listeners {
+		wg.Add(1)
+		go func(start func() error) {
+			defer wg.Done()
+			err := start()
+			if err != nil {
+				server.Logger().Error("Listener stopped unexpectedly", zap.Error(err))
+				listenerErrors <- err
+			}
+		}(start)
+	}
+
+	jobResult := make(chan error, 1)
+	if options.job != nil {
+		go runJob(lifetime, server, options.job, jobResult)
+	}
+
+	// Readiness is failed first and, when serving traffic, the listeners are kept open for the drain delay
+	// so that the pod is removed from the service endpoints before we stop accepting new connections
+	var result error
+	drain := options.serveBusinessRoutes
+	jobCompleted := false
+
+	select {
+	case sig := <-shutdownChannel:
+		server.Logger().Info("Shutdown requested", zap.String("reason", sig.String()))
+	case <-ctx.Done():
+		server.Logger().Info("Shutdown requested", zap.String("reason", ctx.Err().Error()))
+	case result = <-hostedServiceFaults:
+		server.Logger().Info("Shutdown requested", zap.String("reason", result.Error()))
+	case result = <-listenerErrors:
+		drain = false
+	case result = <-jobResult:
+		drain = false
+		jobCompleted = true
+	}
+
+	server.lifecycle.markStopping()
+
+	if drain {
+		defaultDrainDelay := 5 * time.Second
+		if options.development {
+			defaultDrainDelay = 0
+		}
+
+		drainDelay := config.GetDurationValueOrDefault("server.shutdown.drainDelay", defaultDrainDelay)
+		server.Logger().Info("Draining connections", zap.Duration("drainDelay", drainDelay))
+		time.Sleep(drainDelay)
+	}
+
+	server.Logger().Info("Application stopping...")
+
+	// wait for in-flight requests to complete before stopping the services they may be using
+	shutdownTimeout := config.GetDurationValueOrDefault("server.shutdown.timeout", 30*time.Second)
+	for listener := range listeners {
+		err := shutdownWithTimeout(listener, shutdownTimeout)
+		if err != nil {
+			server.Logger().Error("Unable to shutdown listener", zap.Error(err))
+		}
+	}
+	wg.Wait()
+
+	cancelLifetime()
+
+	// an interrupted job is given the chance to stop, its result determines whether it was successful
+	if options.job != nil && !jobCompleted {
+		select {
+		case err := <-jobResult:
+			if result == nil {
+				result = err
+			}
+		case <-time.After(serviceTimeout):
+			if result == nil {
+				result = fmt.Errorf("job did not stop within %s", serviceTimeout)
+			}
+		}
+	}
+
+	if options.job != nil {
+		if result != nil {
+			server.Logger().Error("Job failed", zap.Error(result))
+		} else {
+			server.Logger().Info("Job completed")
+		}
+	}
+
+	stopServices()
+	return result
+}
+
+// StartHostedServices starts the hosted services without opening the listeners so that the server can be
+// hosted in-process, ie: by servertest. The services are stopped by StopHostedServices
+func (server *Server) StartHostedServices(ctx context.Context) error {
+	err := server.hostedServices.start(ctx, server.Logger())
+	if err != nil {
+		server.StopHostedServices()
+	}
+	return err
+}
+
+// StopHostedServices stops the hosted services started by StartHostedServices in the reverse order
+func (server *Server) StopHostedServices() {
+	server.hostedServices.stop(server.GetConfiguration().GetDurationValueOrDefault("server.shutdown.serviceTimeout", 10*time.Second), server.Logger())
+}
+
+// NewApp builds the fiber app with the middleware, system routes and handlers without listening so that the
+// routes can be exercised in-process using app.Test
+func (server *Server) NewApp(development bool) (*fiber.App, error) {
+	app, _, err := newBusinessApp(server, development)
+	if err != nil {
+		return nil, err
+	}
+
+	configureSystemHandlers(app, server, development)
+	if server.handlerConfig != nil {
+		server.handlerConfig(app, server)
+	}
+	server.routes = app
+
+	return app, nil
+}
+
+func newBusinessApp(server *Server, development bool) (*fiber.App, *tls.Config, error) {
+	config := server.GetConfiguration()
+	fiberConfig := fiber.Config{
 		AppName:               server.AppName,
 		DisableDefaultDate:    true,
 		DisableStartupMessage: !development,
 		EnablePrintRoutes:     development,
-		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-			// Status code defaults to 500
-			code := fiber.StatusInternalServerError
-
-			// Retrieve the custom status code if it's an fiber.*Error
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
+		ErrorHandler:          newErrorHandler(development, server.errorMappers),
+	}
+	applyServerConfiguration(&fiberConfig, config)
 
-			// Send custom error json
-			errorResult := map[string]interface{}{
-				"message": "An error occurred whilst processing your request. Please check the logs for more information.",
-			}
+	tlsConfig, err := getTLSConfig(server, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tlsConfig != nil && fiberConfig.Prefork {
+		server.Logger().Warn("Prefork is not supported with reloadable TLS certificates and will be ignored")
+		fiberConfig.Prefork = false
+	}
 
-			if development {
-				errorResult["error"] = err.Error()
-			}
+	app := fiber.New(fiberConfig)
 
-			ctx.Status(code).JSON(errorResult)
-			return nil
-		},
+	// Logging must be the first middleware after the request id or we miss 500 status codes
+	app.Use(requestid.New())
+	payloads := NewPayloadLogging(getPayloadLoggingConfig(*config))
+	config.RegisterChangeNotificationHandler(func(config configuration.ConfigurationRoot) {
+		payloads.Update(getPayloadLoggingConfig(config))
 	})
-
-	// Logging must be the first middleware or we miss 500 status codes
-	app.Use(NewHttpLoggingMiddleware(&LoggingConfig{}))
+	app.Use(NewHttpLoggingMiddleware(&LoggingConfig{
+		ExcludedPaths: config.GetStringSliceValueOrDefault("log.http.excludedPaths", []string{"/_system/health", "/_system/metrics"}),
+		SampleEvery:   config.GetIntValueOrDefault("log.http.sampleEvery", 0),
+		ServerTiming:  config.GetBooleanValueOrDefault("server.timing.enabled", development),
+		Payloads:      payloads,
+		Logger:        server.logger,
+	}))
 	app.Use(recover.New())
+	app.Use(newRequestScopeMiddleware(server))
+	// compression wraps the registered middleware so that idempotency and caching store uncompressed responses
+	// which are compressed for each client according to its Accept-Encoding
 	app.Use(compress.New(compress.Config{
 		Level: compress.LevelBestSpeed,
 	}))
+	for _, factory := range server.middleware {
+		app.Use(factory(server))
+	}
+
+	return app, tlsConfig, nil
+}
+
+func newManagementApp(server *Server, development bool) *fiber.App {
+	app := fiber.New(fiber.Config{
+		AppName:               server.AppName,
+		DisableDefaultDate:    true,
+		DisableStartupMessage: true,
+		ErrorHandler:          newErrorHandler(development, server.errorMappers),
+	})
+	app.Use(recover.New())
+	app.Use(newRequestScopeMiddleware(server))
+
+	return app
+}
+
+func configureSystemHandlers(app *fiber.App, server *Server, development bool) {
+	configureHealthHandlers(app, server)
+	configureInfoHandlers(app, server)
+	configureOpenAPIHandlers(app, server, development)
+	for _, handlerConfig := range server.systemHandlerConfig {
+		handlerConfig(app, server)
+	}
+}
 
+func configureInfoHandlers(app *fiber.App, server *Server) {
+	app.Get("/_system/info", func(context *fiber.Ctx) error {
+		info := buildinfo.Get()
+		return context.JSON(fiber.Map{
+			"name":       server.AppName,
+			"build":      info,
+			"attributes": info.Attributes(server.AppName),
+		})
+	})
+}
+
+func configureHealthHandlers(app *fiber.App, server *Server) {
 	app.Get("/_system/health/:type?", func(context *fiber.Ctx) error {
 		var result healthchecks.HealthCheckAggregatedResult
 
@@ -126,38 +478,30 @@ func runServer(server *Server, development bool) {
 
 		return nil
 	})
+}
 
-	if server.handlerConfig != nil {
-		server.handlerConfig(app, server)
-	}
-
-	// Handle graceful shutdown by proxying with a channel
-	shutdownChannel := make(chan os.Signal, 1)
-	signal.Notify(shutdownChannel, os.Interrupt)
-
-	// Use a GoRoutine to monitor the channel and call app.Shutdown
-	go func() {
-		<-shutdownChannel
-		log.Logger.Info("Application stopping...")
-		err := app.Shutdown()
-		if err != nil {
-			log.Logger.Panic(err.Error())
+// newErrorHandler renders every error as application/problem+json, internal details such as the underlying
+// error message are only included in development
+func newErrorHandler(development bool, mappers []problems.Mapper) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		problem := problems.From(err, mappers...)
+		problem.Instance = ctx.Path()
+		problem.RequestId = getRequestId(ctx)
+		problem.TraceId = GetTraceId(ctx)
+
+		if development {
+			problem.Internal = err.Error()
+		} else {
+			problem.Internal = ""
 		}
-	}()
 
-	log.Logger.Info("Application starting...")
-	err := app.Listen(":" + server.GetConfiguration().GetStringValueOrDefault("server.port", "5000"))
-	if err != nil {
-		log.Logger.Panic(err.Error())
-	}
-
-	for key, svc := range server.services {
-		disposable, castSuccessful := (*svc).(Disposable)
-		if castSuccessful {
-			log.Logger.Info(fmt.Sprintf("Deregistering service: %s", key))
-			disposable.Dispose()
+		body, marshalErr := json.Marshal(problem)
+		if marshalErr != nil {
+			return marshalErr
 		}
-	}
 
-	log.Logger.Sync()
+		ctx.Status(problem.Status)
+		ctx.Set(fiber.HeaderContentType, problems.ContentType)
+		return ctx.Send(body)
+	}
 }
@@ -1,11 +1,22 @@
 package server
 
 import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/audit"
+	"github.com/projectkeas/sdks-service/authentication"
+	"github.com/projectkeas/sdks-service/buildinfo"
 	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/container"
 	"github.com/projectkeas/sdks-service/healthchecks"
 	"github.com/projectkeas/sdks-service/healthchecks/configHealthCheck"
+	"github.com/projectkeas/sdks-service/httpcache"
+	"github.com/projectkeas/sdks-service/idempotency"
 	log "github.com/projectkeas/sdks-service/logger"
 	"github.com/projectkeas/sdks-service/opa"
+	"github.com/projectkeas/sdks-service/problems"
+	"go.uber.org/zap"
 )
 
 type ServerBuilder struct {
@@ -17,33 +28,109 @@ type ServerBuilder struct {
 	requiredSecrets        []string
 	configurationProviders []configuration.ConfigurationProvider
 	handlerConfig          FiberAppFunc
+	systemHandlerConfig    []FiberAppFunc
+	middleware             []middlewareFactory
+	tlsSecret              string
 	livenessChecks         []healthchecks.HealthCheck
 	readinessChecks        []healthchecks.HealthCheck
 	services               map[string]interface{}
+	serviceOrder           []string
+	hostedServices         []hostedServiceRegistration
+	container              *container.Builder
+	priorityProviders      []configuration.ConfigurationProvider
+	providerFactory        ProviderFactory
+	logger                 *zap.Logger
+	scopedLogger           bool
+	errorMappers           []problems.Mapper
+	audit                  *audit.Options
+	responseCaching        middlewareFactory
 }
 
+// ProviderFactory creates the provider for a ConfigMap or Secret, the provider type is either
+// KubernetesConfigMap or KubernetesSecret
+type ProviderFactory func(providerType string, name string) configuration.ConfigurationProvider
+
 func New(appName string) *ServerBuilder {
 	return &ServerBuilder{
-		AppName:  appName,
-		services: map[string]interface{}{},
+		AppName:   appName,
+		services:  map[string]interface{}{},
+		container: container.NewBuilder(),
 	}
 }
 
+// Clone returns a builder with the same configuration that can be changed and built without affecting this
+// builder, ie: to build several servers from a shared base. Services and hosted services are shared
+func (builder *ServerBuilder) Clone() *ServerBuilder {
+	clone := *builder
+	clone.configMaps = append([]string{}, builder.configMaps...)
+	clone.requiredConfigMaps = append([]string{}, builder.requiredConfigMaps...)
+	clone.secrets = append([]string{}, builder.secrets...)
+	clone.requiredSecrets = append([]string{}, builder.requiredSecrets...)
+	clone.configurationProviders = append([]configuration.ConfigurationProvider{}, builder.configurationProviders...)
+	clone.systemHandlerConfig = append([]FiberAppFunc{}, builder.systemHandlerConfig...)
+	clone.middleware = append([]middlewareFactory{}, builder.middleware...)
+	clone.livenessChecks = append([]healthchecks.HealthCheck{}, builder.livenessChecks...)
+	clone.readinessChecks = append([]healthchecks.HealthCheck{}, builder.readinessChecks...)
+	clone.serviceOrder = append([]string{}, builder.serviceOrder...)
+	clone.hostedServices = append([]hostedServiceRegistration{}, builder.hostedServices...)
+	clone.priorityProviders = append([]configuration.ConfigurationProvider{}, builder.priorityProviders...)
+	clone.errorMappers = append([]problems.Mapper{}, builder.errorMappers...)
+	clone.container = builder.container.Clone()
+
+	clone.services = map[string]interface{}{}
+	for key, value := range builder.services {
+		clone.services[key] = value
+	}
+
+	return &clone
+}
+
 func (builder *ServerBuilder) Build() *Server {
 	return builder.BuildForDevelopment(false)
 }
 
 func (builder *ServerBuilder) BuildForDevelopment(isDevelopment bool) *Server {
 
-	server := newServer(builder.AppName, builder.handlerConfig)
+	middleware := builder.middleware[:len(builder.middleware):len(builder.middleware)]
+	if builder.responseCaching != nil {
+		middleware = append(middleware, builder.responseCaching)
+	}
+
+	server := newServer(builder.AppName, builder.handlerConfig, middleware)
+	server.tlsSecret = builder.tlsSecret
+	if builder.logger != nil {
+		server.logger = log.WithRedaction(builder.logger)
+	}
+	server.systemHandlerConfig = builder.systemHandlerConfig
+	server.errorMappers = builder.errorMappers
 	config := setupConfig(builder, isDevelopment, func(config configuration.ConfigurationRoot) {
-		log.Initialize(log.Config{
-			AppName:       builder.AppName,
-			LogLevel:      config.GetStringValueOrDefault("log.level", "debug"),
-			IsDevelopment: isDevelopment,
+		err := log.ConfigureRedaction(log.RedactionConfig{
+			Disabled: config.GetBooleanValueOrDefault("log.redaction.disabled", false),
+			Keys:     config.GetStringSliceValueOrDefault("log.redaction.keys", log.DefaultRedactedKeys),
+			Patterns: config.GetStringSliceValueOrDefault("log.redaction.patterns", log.DefaultRedactionPatterns),
+			Regex:    config.GetStringValueOrDefault("log.redaction.regex", ""),
 		})
+		if err != nil {
+			log.Logger.Error("Unable to configure log redaction, the previous rules remain in place", zap.Error(err))
+		}
+
+		if builder.logger == nil {
+			log.ConfigureLevels(config.GetStringValueOrDefault("log.level", "debug"), func(component string) string {
+				return config.GetStringValueOrDefault("log.levels."+component, "")
+			})
+		}
 	})
 
+	// the logger is built once the providers have been attached, only the levels and redaction rules are
+	// updated when the configuration changes so changes to the encoding, schema or outputs require a restart
+	if builder.logger != nil {
+		if !builder.scopedLogger {
+			log.Use(builder.logger)
+		}
+	} else {
+		log.Initialize(getLoggerConfig(builder.AppName, isDevelopment, config))
+	}
+
 	// Ensure that we add health checks for the required properties
 	for _, key := range builder.requiredConfigMaps {
 		builder.WithReadinessHealthCheck(configHealthCheck.NewKubernetesConfigMapCheck(key, config))
@@ -52,23 +139,178 @@ func (builder *ServerBuilder) BuildForDevelopment(isDevelopment bool) *Server {
 		builder.WithReadinessHealthCheck(configHealthCheck.NewKubernetesSecretCheckCheck(key, config))
 	}
 
+	builder.WithReadinessHealthCheck(server.lifecycle)
+
+	if len(builder.hostedServices) > 0 {
+		for _, registration := range builder.hostedServices {
+			server.hostedServices.add(registration.name, registration.service, registration.dependsOn)
+		}
+		builder.WithLivenessHealthCheck(server.hostedServices)
+	}
+
+	if builder.audit != nil {
+		options := *builder.audit
+		if options.Service == "" {
+			options.Service = builder.AppName
+		}
+		if options.TraceId == nil {
+			options.TraceId = GetTraceId
+		}
+		server.audit = audit.New(config, options)
+		builder.WithService(audit.SERVICE_NAME, server.audit)
+		container.RegisterInstance(builder.container, server.audit)
+	}
+
 	// Register default services
+	healthCheckRunner := healthchecks.NewFromHealthChecks(builder.livenessChecks, builder.readinessChecks)
+	opaService := opa.New()
 	builder.WithService(configuration.SERVICE_NAME, config)
-	builder.WithService(healthchecks.SERVICE_NAME, healthchecks.NewFromHealthChecks(builder.livenessChecks, builder.readinessChecks))
-	builder.WithService(opa.SERVICE_NAME, opa.OPAService{})
+	builder.WithService(healthchecks.SERVICE_NAME, healthCheckRunner)
+	// the service is registered by name as a value for compatibility with (*service).(opa.OPAService), the
+	// value shares its policies with the instance resolved from the container
+	builder.WithService(opa.SERVICE_NAME, *opaService)
+
+	container.RegisterInstance(builder.container, config)
+	container.RegisterInstance(builder.container, &healthCheckRunner)
+	container.RegisterInstance(builder.container, opaService)
+	container.RegisterInstance(builder.container, &server)
 
-	for key, svc := range builder.services {
-		server.RegisterService(key, svc)
+	serviceContainer, err := builder.container.Build()
+	if err != nil {
+		panic(err)
+	}
+	server.container = serviceContainer
+
+	// the default services are registered first so that they are disposed of last
+	defaultServices := []string{configuration.SERVICE_NAME, healthchecks.SERVICE_NAME, opa.SERVICE_NAME}
+	for _, key := range append(defaultServices, builder.serviceOrder...) {
+		if _, err := server.GetService(key); err != nil {
+			server.RegisterService(key, builder.services[key])
+		}
 	}
 
 	return &server
 }
 
+// Container returns the builder for the type safe service container, services registered here can be
+// resolved with container.Resolve from Server.Container() or the request scope via GetRequestScope
+func (builder *ServerBuilder) Container() *container.Builder {
+	return builder.container
+}
+
 func (builder *ServerBuilder) ConfigureHandlers(handlerConfig FiberAppFunc) *ServerBuilder {
 	builder.handlerConfig = handlerConfig
 	return builder
 }
 
+// WithCors enables CORS using the server.cors.* configuration keys
+func (builder *ServerBuilder) WithCors() *ServerBuilder {
+	builder.middleware = append(builder.middleware, func(server *Server) fiber.Handler {
+		return NewCorsMiddleware(server.GetConfiguration())
+	})
+	return builder
+}
+
+// WithSecurityHeaders enables the security headers using the server.security.* configuration keys
+func (builder *ServerBuilder) WithSecurityHeaders() *ServerBuilder {
+	builder.middleware = append(builder.middleware, func(server *Server) fiber.Handler {
+		return NewSecurityHeadersMiddleware(server.GetConfiguration())
+	})
+	return builder
+}
+
+// WithRateLimiting enables rate limiting using the server.ratelimit.* configuration keys. Register it after
+// WithAuthentication when keying by principal
+func (builder *ServerBuilder) WithRateLimiting() *ServerBuilder {
+	builder.middleware = append(builder.middleware, func(server *Server) fiber.Handler {
+		return NewRateLimitMiddleware(server.GetConfiguration())
+	})
+	return builder
+}
+
+// WithAuthentication validates API keys and JWTs on every non system route using the auth.* configuration
+// keys. When a policy is specified it is evaluated using the server's OPA service unless one is provided
+func (builder *ServerBuilder) WithAuthentication(options authentication.Options) *ServerBuilder {
+	builder.middleware = append(builder.middleware, func(server *Server) fiber.Handler {
+		if options.Next == nil {
+			options.Next = isSystemRoute
+		}
+		if options.OPA == nil {
+			options.OPA = server.GetOPAService()
+		}
+		if options.OnDecision == nil && server.audit != nil {
+			options.OnDecision = server.audit.RecordDecision
+		}
+		if options.Logger == nil {
+			options.Logger = server.Logger()
+		}
+		return authentication.New(server.GetConfiguration(), options)
+	})
+	return builder
+}
+
+// WithAudit writes audit events to the sinks configured by audit.sinks (stdout, file or cloudevents). The
+// decisions of the authentication policy are audited and handlers can record events using GetAuditService
+func (builder *ServerBuilder) WithAudit(options audit.Options) *ServerBuilder {
+	builder.audit = &options
+	return builder
+}
+
+// WithIdempotency replays the first response for requests that share an Idempotency-Key header using the
+// server.idempotency.* configuration keys. Register it after WithAuthentication so keys are scoped to the caller
+func (builder *ServerBuilder) WithIdempotency(options idempotency.Options) *ServerBuilder {
+	builder.middleware = append(builder.middleware, func(server *Server) fiber.Handler {
+		if options.Next == nil {
+			options.Next = isSystemRoute
+		}
+		return idempotency.New(server.GetConfiguration(), options)
+	})
+	return builder
+}
+
+// WithResponseCaching computes ETags for GET responses and caches the routes matching server.cache.rules.
+// The cache is registered with the container so that responses can be invalidated by tag. It's always
+// installed after the other middleware, regardless of the order it's registered in, so that cached responses
+// are only served once the request has been authenticated and are scoped to the principal
+func (builder *ServerBuilder) WithResponseCaching(options httpcache.Options) *ServerBuilder {
+	if options.Cache == nil {
+		options.Cache = httpcache.NewCache()
+	}
+	container.RegisterInstance(builder.container, options.Cache)
+
+	builder.responseCaching = func(server *Server) fiber.Handler {
+		if options.Next == nil {
+			options.Next = isSystemRoute
+		}
+		if options.Logger == nil {
+			options.Logger = server.Logger()
+		}
+		return httpcache.New(server.GetConfiguration(), options)
+	}
+	return builder
+}
+
+// ConfigureSystemHandlers registers routes such as metrics or diagnostics under /_system, these are served
+// from the management port when server.management.port is configured
+func (builder *ServerBuilder) ConfigureSystemHandlers(handlerConfig FiberAppFunc) *ServerBuilder {
+	builder.systemHandlerConfig = append(builder.systemHandlerConfig, handlerConfig)
+	return builder
+}
+
+// WithDebugEndpoints mounts pprof, runtime, build, service and configuration diagnostics under /_system/debug
+// along with PUT and DELETE /_system/debug/log/level endpoints. The routes require the token configured in
+// server.debug.adminToken and aren't served when it's not configured
+func (builder *ServerBuilder) WithDebugEndpoints() *ServerBuilder {
+	return builder.ConfigureSystemHandlers(configureDebugHandlers)
+}
+
+// WithErrorMapper converts errors from third party packages into problems before they're rendered, mappers
+// are evaluated in the order they're registered
+func (builder *ServerBuilder) WithErrorMapper(mapper problems.Mapper) *ServerBuilder {
+	builder.errorMappers = append(builder.errorMappers, mapper)
+	return builder
+}
+
 func (builder *ServerBuilder) WithInMemoryConfiguration(name string, data map[string]string) *ServerBuilder {
 	return builder.WithConfigurationProvider(*configuration.NewInMemoryConfigurationProvider(name, data))
 }
@@ -82,6 +324,36 @@ func (builder *ServerBuilder) WithConfigurationProvider(provider configuration.C
 	return builder
 }
 
+// WithPriorityConfigurationProvider adds a provider whose values take precedence over every other provider
+func (builder *ServerBuilder) WithPriorityConfigurationProvider(provider configuration.ConfigurationProvider) *ServerBuilder {
+	builder.priorityProviders = append(builder.priorityProviders, provider)
+	return builder
+}
+
+// WithProviderFactory replaces the providers created for ConfigMaps and Secrets, typically so that a
+// server can be built without access to a cluster
+func (builder *ServerBuilder) WithProviderFactory(factory ProviderFactory) *ServerBuilder {
+	builder.providerFactory = factory
+	return builder
+}
+
+// WithLogger uses the specified logger rather than building one from the log.* configuration keys. The server
+// logs through it directly, packages without access to the server log through the global logger which it replaces
+func (builder *ServerBuilder) WithLogger(logger *zap.Logger) *ServerBuilder {
+	builder.logger = logger
+	builder.scopedLogger = false
+	return builder
+}
+
+// WithScopedLogger uses the specified logger for the server without replacing the global logger so that several
+// servers can run in the same process, ie: parallel tests. Packages without access to the server continue to log
+// through the global logger
+func (builder *ServerBuilder) WithScopedLogger(logger *zap.Logger) *ServerBuilder {
+	builder.logger = logger
+	builder.scopedLogger = true
+	return builder
+}
+
 func (builder *ServerBuilder) WithConfigMap(name string) *ServerBuilder {
 	builder.configMaps = append(builder.configMaps, name)
 	return builder
@@ -102,6 +374,13 @@ func (builder *ServerBuilder) WithRequiredSecret(name string) *ServerBuilder {
 	return builder.WithSecret(name)
 }
 
+// WithTLSSecret serves TLS using the tls.crt and tls.key entries of the named Kubernetes Secret, the
+// certificate is reloaded whenever the secret is rotated
+func (builder *ServerBuilder) WithTLSSecret(name string) *ServerBuilder {
+	builder.tlsSecret = name
+	return builder.WithRequiredSecret(name)
+}
+
 func (builder *ServerBuilder) WithReadinessHealthCheck(healthCheck healthchecks.HealthCheck) *ServerBuilder {
 	builder.readinessChecks = append(builder.readinessChecks, healthCheck)
 	return builder
@@ -113,28 +392,100 @@ func (builder *ServerBuilder) WithLivenessHealthCheck(healthCheck healthchecks.H
 }
 
 func (builder *ServerBuilder) WithService(name string, service interface{}) *ServerBuilder {
+	if _, found := builder.services[name]; !found {
+		builder.serviceOrder = append(builder.serviceOrder, name)
+	}
+
 	builder.services[name] = service
 	return builder
 }
 
+// WithHostedService registers a service that is started, after the services it depends on, before the
+// listeners are opened and stopped in reverse order on shutdown. Hosted services are also registered as
+// regular services so that they can be retrieved by name
+func (builder *ServerBuilder) WithHostedService(name string, service HostedService, dependsOn ...string) *ServerBuilder {
+	builder.hostedServices = append(builder.hostedServices, hostedServiceRegistration{
+		name:      name,
+		service:   service,
+		dependsOn: dependsOn,
+	})
+	return builder.WithService(name, service)
+}
+
+func getLoggerConfig(appName string, isDevelopment bool, config *configuration.ConfigurationRoot) log.Config {
+	build := buildinfo.Get()
+	return log.Config{
+		AppName:       appName,
+		LogLevel:      config.GetStringValueOrDefault("log.level", "debug"),
+		IsDevelopment: isDevelopment,
+		Version:       build.Version,
+		CommitSha:     build.Commit,
+		PodName:       build.Kubernetes.PodName,
+		Namespace:     build.Kubernetes.Namespace,
+		NodeName:      build.Kubernetes.NodeName,
+		Encoding:      config.GetStringValueOrDefault("log.encoding", log.Encoding_Json),
+		Schema:        config.GetStringValueOrDefault("log.schema", log.Schema_Default),
+		Outputs:       config.GetStringSliceValueOrDefault("log.outputs", []string{"stdout"}),
+		File: log.FileConfig{
+			MaxSizeMB:  config.GetIntValueOrDefault("log.file.maxSize", 100),
+			MaxBackups: config.GetIntValueOrDefault("log.file.maxBackups", 5),
+			MaxAgeDays: config.GetIntValueOrDefault("log.file.maxAge", 0),
+			Compress:   config.GetBooleanValueOrDefault("log.file.compress", false),
+		},
+		Sampling: log.SamplingConfig{
+			Tick:       config.GetDurationValueOrDefault("log.sampling.tick", time.Second),
+			Initial:    config.GetIntValueOrDefault("log.sampling.initial", 0),
+			Thereafter: config.GetIntValueOrDefault("log.sampling.thereafter", 100),
+			Levels:     config.GetStringSliceValueOrDefault("log.sampling.levels", []string{"debug", "info"}),
+		},
+		DedupeWindow: config.GetDurationValueOrDefault("log.dedupe.window", 0),
+		GcpProjectId: config.GetStringValueOrDefault("log.gcp.projectId", ""),
+	}
+}
+
 func setupConfig(builder *ServerBuilder, development bool, callback func(configuration.ConfigurationRoot)) *configuration.ConfigurationRoot {
 
 	configurationBuilder := configuration.NewConfigurationBuilder(development)
 
-	for _, name := range builder.configMaps {
-		configurationBuilder.AddObservableConfigurationProvider(configuration.NewKubernetesConfigMapConfigurationProvider(name))
-	}
-
-	for _, name := range builder.secrets {
-		configurationBuilder.AddObservableConfigurationProvider(configuration.NewKubernetesSecretConfigurationProvider(name))
+	for _, provider := range builder.priorityProviders {
+		configurationBuilder.AddConfigurationProvider(provider)
 	}
 
 	for _, provider := range builder.configurationProviders {
 		configurationBuilder.AddConfigurationProvider(provider)
 	}
 
+	for _, name := range builder.configMaps {
+		addProvider(configurationBuilder, builder.newProvider("KubernetesConfigMap", name))
+	}
+
+	for _, name := range builder.secrets {
+		addProvider(configurationBuilder, builder.newProvider("KubernetesSecret", name))
+	}
+
 	config := configurationBuilder.Build(callback)
 	callback(*config)
 
 	return config
 }
+
+func (builder *ServerBuilder) newProvider(providerType string, name string) configuration.ConfigurationProvider {
+	if builder.providerFactory != nil {
+		return builder.providerFactory(providerType, name)
+	}
+
+	if providerType == "KubernetesSecret" {
+		return configuration.NewKubernetesSecretConfigurationProvider(name)
+	}
+
+	return configuration.NewKubernetesConfigMapConfigurationProvider(name)
+}
+
+func addProvider(configurationBuilder *configuration.ConfigurationBuilder, provider configuration.ConfigurationProvider) {
+	if observable, ok := provider.(configuration.ObservableConfigurationProvider); ok {
+		configurationBuilder.AddObservableConfigurationProvider(observable)
+		return
+	}
+
+	configurationBuilder.AddConfigurationProvider(provider)
+}
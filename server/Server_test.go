@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/problems"
+)
+
+func TestErrorHandlerOnlyIncludesInternalDetailsInDevelopment(t *testing.T) {
+	shared := problems.NewConflictError("the order has been shipped")
+	mapper := func(err error) (*problems.Problem, bool) {
+		return shared, true
+	}
+
+	for _, development := range []bool{false, true} {
+		app := fiber.New(fiber.Config{ErrorHandler: newErrorHandler(development, []problems.Mapper{mapper})})
+		app.Get("/orders/:id", func(c *fiber.Ctx) error {
+			return errors.New("order 1 has been shipped to jane.doe@example.com")
+		})
+
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/orders/1", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		problem := problems.Problem{}
+		if err := json.Unmarshal(body, &problem); err != nil {
+			t.Fatal(err)
+		}
+
+		if problem.Status != fiber.StatusConflict || problem.Instance != "/orders/1" {
+			t.Errorf("expected the mapped problem for the request, got %s", body)
+		}
+		if (problem.Internal != "") != development {
+			t.Errorf("expected the internal details to be included only in development (%t), got %q", development, problem.Internal)
+		}
+	}
+
+	if shared.Instance != "" || shared.Internal != "" {
+		t.Errorf("expected the problem returned by the mapper not to be modified, got %+v", shared)
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTraceId returns the trace id propagated by the caller using the W3C traceparent, B3 or Google Cloud
+// trace context headers
+func GetTraceId(c *fiber.Ctx) string {
+	// traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(c.Get("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+
+	if traceId := c.Get("X-B3-TraceId"); traceId != "" {
+		return traceId
+	}
+
+	// X-Cloud-Trace-Context: TRACE_ID/SPAN_ID;o=TRACE_TRUE
+	if cloudTrace := c.Get("X-Cloud-Trace-Context"); cloudTrace != "" {
+		return strings.SplitN(cloudTrace, "/", 2)[0]
+	}
+
+	return ""
+}
+
+func getRequestId(c *fiber.Ctx) string {
+	if rid := c.GetRespHeader(fiber.HeaderXRequestID); rid != "" {
+		return rid
+	}
+	return c.Get(fiber.HeaderXRequestID)
+}
@@ -0,0 +1,82 @@
+package servertest
+
+import (
+	"sync"
+
+	log "github.com/projectkeas/sdks-service/logger"
+)
+
+// Provider is a thread safe, mutable configuration provider that stands in for ConfigMaps and Secrets
+type Provider struct {
+	name         string
+	providerType string
+	data         map[string]string
+	mutex        *sync.RWMutex
+}
+
+func NewProvider(providerType string, name string, data map[string]string) *Provider {
+	copy := map[string]string{}
+	for key, value := range data {
+		copy[key] = value
+	}
+
+	provider := &Provider{
+		name:         name,
+		providerType: providerType,
+		data:         copy,
+		mutex:        &sync.RWMutex{},
+	}
+	provider.registerSensitiveValues()
+	return provider
+}
+
+func (provider *Provider) Name() string {
+	return provider.name
+}
+
+func (provider *Provider) Type() string {
+	return provider.providerType
+}
+
+// ResourceExists reports the ConfigMaps and Secrets registered on the builder as existing so that the
+// readiness checks for required resources pass
+func (provider *Provider) ResourceExists() bool {
+	return true
+}
+
+func (provider *Provider) TryGetValue(key string) (bool, string) {
+	provider.mutex.RLock()
+	defer provider.mutex.RUnlock()
+
+	data, found := provider.data[key]
+	return found, data
+}
+
+func (provider *Provider) set(key string, value string) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	provider.data[key] = value
+	provider.registerSensitiveValues()
+}
+
+func (provider *Provider) delete(key string) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	delete(provider.data, key)
+	provider.registerSensitiveValues()
+}
+
+// registerSensitiveValues mirrors the Kubernetes secret provider so that secret values are redacted from the logs
+func (provider *Provider) registerSensitiveValues() {
+	if provider.providerType != "KubernetesSecret" {
+		return
+	}
+
+	values := []string{}
+	for _, value := range provider.data {
+		values = append(values, value)
+	}
+	log.SetSensitiveValues(provider.providerType+"/"+provider.name, values)
+}
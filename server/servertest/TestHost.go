@@ -0,0 +1,203 @@
+package servertest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/configuration"
+	"github.com/projectkeas/sdks-service/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const overridesProviderName string = "servertest"
+
+type options struct {
+	configuration map[string]string
+	configMaps    map[string]map[string]string
+	secrets       map[string]map[string]string
+	services      map[string]interface{}
+	logLevel      zapcore.Level
+	development   bool
+}
+
+type Option func(*options)
+
+// WithConfiguration sets values that take precedence over every other configuration provider
+func WithConfiguration(values map[string]string) Option {
+	return func(o *options) {
+		for key, value := range values {
+			o.configuration[key] = value
+		}
+	}
+}
+
+// WithConfigMap sets the data of a ConfigMap registered on the builder, ConfigMaps without data are empty
+func WithConfigMap(name string, data map[string]string) Option {
+	return func(o *options) {
+		o.configMaps[name] = data
+	}
+}
+
+// WithSecret sets the data of a Secret registered on the builder, Secrets without data are empty
+func WithSecret(name string, data map[string]string) Option {
+	return func(o *options) {
+		o.secrets[name] = data
+	}
+}
+
+// WithService registers or replaces a named service before the server is built
+func WithService(name string, service interface{}) Option {
+	return func(o *options) {
+		o.services[name] = service
+	}
+}
+
+// WithLogLevel sets the minimum level of the captured logs, defaults to debug
+func WithLogLevel(level zapcore.Level) Option {
+	return func(o *options) {
+		o.logLevel = level
+	}
+}
+
+// WithDevelopment builds the server and app in development mode
+func WithDevelopment() Option {
+	return func(o *options) {
+		o.development = true
+	}
+}
+
+// TestHost builds a Server in-process without binding a port or connecting to Kubernetes, the hosted services
+// are started when the host is created and stopped by Close
+type TestHost struct {
+	Server *server.Server
+
+	app       *fiber.App
+	logs      *observer.ObservedLogs
+	cancel    context.CancelFunc
+	overrides *Provider
+	providers map[string]*Provider
+}
+
+// New builds the server from a copy of the builder so that the same builder can be used for several hosts
+func New(builder *server.ServerBuilder, opts ...Option) (*TestHost, error) {
+	builder = builder.Clone()
+
+	o := &options{
+		configuration: map[string]string{},
+		configMaps:    map[string]map[string]string{},
+		secrets:       map[string]map[string]string{},
+		services:      map[string]interface{}{},
+		logLevel:      zapcore.DebugLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	core, logs := observer.New(o.logLevel)
+	host := &TestHost{
+		logs:      logs,
+		overrides: NewProvider("InMemory", overridesProviderName, o.configuration),
+		providers: map[string]*Provider{},
+	}
+
+	// the logger is scoped to the server so that hosts created by parallel tests don't replace each other's logger
+	builder.WithScopedLogger(zap.New(core))
+	builder.WithPriorityConfigurationProvider(host.overrides)
+	builder.WithProviderFactory(func(providerType string, name string) configuration.ConfigurationProvider {
+		data := o.configMaps[name]
+		if providerType == "KubernetesSecret" {
+			data = o.secrets[name]
+		}
+
+		provider := NewProvider(providerType, name, data)
+		host.providers[providerKey(providerType, name)] = provider
+		return provider
+	})
+
+	for name, service := range o.services {
+		builder.WithService(name, service)
+	}
+
+	if o.development {
+		host.Server = builder.BuildForDevelopment(true)
+	} else {
+		host.Server = builder.Build()
+	}
+
+	app, err := host.Server.NewApp(o.development)
+	if err != nil {
+		return nil, err
+	}
+	host.app = app
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = host.Server.StartHostedServices(ctx)
+	if err != nil {
+		cancel()
+		host.Server.Dispose()
+		return nil, err
+	}
+	host.cancel = cancel
+
+	return host, nil
+}
+
+// App returns the configured fiber app for use with app.Test
+func (host *TestHost) App() *fiber.App {
+	return host.app
+}
+
+func (host *TestHost) Test(req *http.Request, msTimeout ...int) (*http.Response, error) {
+	return host.app.Test(req, msTimeout...)
+}
+
+// Logs returns every log entry written since the host was created
+func (host *TestHost) Logs() *observer.ObservedLogs {
+	return host.logs
+}
+
+// SetConfiguration overrides a configuration value and synchronously notifies the change handlers
+func (host *TestHost) SetConfiguration(key string, value string) {
+	host.overrides.set(key, value)
+	host.Server.GetConfiguration().Reload()
+}
+
+// DeleteConfiguration removes an overridden configuration value and synchronously notifies the change handlers
+func (host *TestHost) DeleteConfiguration(key string) {
+	host.overrides.delete(key)
+	host.Server.GetConfiguration().Reload()
+}
+
+// SetConfigMapValue changes a value in a ConfigMap registered on the builder, simulating an update from the cluster
+func (host *TestHost) SetConfigMapValue(name string, key string, value string) {
+	host.setProviderValue("KubernetesConfigMap", name, key, value)
+}
+
+// SetSecretValue changes a value in a Secret registered on the builder, simulating an update from the cluster
+func (host *TestHost) SetSecretValue(name string, key string, value string) {
+	host.setProviderValue("KubernetesSecret", name, key, value)
+}
+
+func (host *TestHost) setProviderValue(providerType string, name string, key string, value string) {
+	provider, found := host.providers[providerKey(providerType, name)]
+	if !found {
+		panic("servertest: " + providerType + " '" + name + "' has not been registered on the builder")
+	}
+
+	provider.set(key, value)
+	host.Server.GetConfiguration().Reload()
+}
+
+func providerKey(providerType string, name string) string {
+	return providerType + "|" + name
+}
+
+// Close stops the hosted services and disposes of the services registered with the server
+func (host *TestHost) Close() {
+	host.cancel()
+	host.Server.StopHostedServices()
+	host.Server.Dispose()
+}
@@ -0,0 +1,127 @@
+package servertest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/projectkeas/sdks-service/healthchecks"
+	log "github.com/projectkeas/sdks-service/logger"
+	"github.com/projectkeas/sdks-service/server"
+)
+
+type recordingService struct {
+	started bool
+	stopped bool
+}
+
+func (service *recordingService) Start(ctx context.Context) error {
+	service.started = true
+	return nil
+}
+
+func (service *recordingService) Stop(ctx context.Context) error {
+	service.stopped = true
+	return nil
+}
+
+func TestHostDoesNotReplaceTheGlobalLogger(t *testing.T) {
+	global := log.Logger
+
+	host, err := New(server.New("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+
+	if log.Logger != global {
+		t.Errorf("expected the global logger to be left in place")
+	}
+}
+
+func TestHostsCaptureTheirOwnLogs(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			builder := server.New(name).ConfigureHandlers(func(app *fiber.App, server *server.Server) {
+				app.Get("/", func(c *fiber.Ctx) error {
+					server.Logger().Info("handled by " + name)
+					return c.SendStatus(fiber.StatusNoContent)
+				})
+			})
+			host, err := New(builder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer host.Close()
+
+			for i := 0; i < 10; i++ {
+				if _, err := host.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if count := host.Logs().FilterMessage("handled by " + name).Len(); count != 10 {
+				t.Errorf("expected 10 entries from %s, got %d", name, count)
+			}
+			for _, entry := range host.Logs().All() {
+				if entry.Message == "handled by first" && name != "first" || entry.Message == "handled by second" && name != "second" {
+					t.Errorf("expected only the entries of %s to be captured, got %q", name, entry.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestHostStartsAndStopsHostedServices(t *testing.T) {
+	service := &recordingService{}
+	host, err := New(server.New("test").WithHostedService("recording", service))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !service.started {
+		t.Errorf("expected the hosted service to be started with the host")
+	}
+	if host.Logs().FilterMessage("Starting hosted service: recording").Len() != 1 {
+		t.Errorf("expected the hosted service logs to be written to the server logger")
+	}
+
+	host.Close()
+	if !service.stopped {
+		t.Errorf("expected the hosted service to be stopped when the host is closed")
+	}
+}
+
+func TestHostsCanShareABuilder(t *testing.T) {
+	builder := server.New("test").WithRequiredConfigMap("settings").WithRequiredSecret("credentials")
+
+	first, err := New(builder, WithConfiguration(map[string]string{"feature.enabled": "true"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	second, err := New(builder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	if second.Server.GetConfiguration().GetBooleanValueOrDefault("feature.enabled", false) {
+		t.Errorf("expected the configuration of the first host not to be applied to the second")
+	}
+
+	for _, host := range []*TestHost{first, second} {
+		readiness := host.Server.GetHealthCheckRunner().RunReadinessChecks()
+		if readiness.State != healthchecks.HealthCheckState_Healthy {
+			t.Errorf("expected the required ConfigMaps and Secrets to be reported as ready, got %+v", readiness.Checks)
+		}
+		if len(readiness.Checks) != 3 {
+			t.Errorf("expected a readiness check for each required resource and the lifecycle, got %d", len(readiness.Checks))
+		}
+	}
+}
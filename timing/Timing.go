@@ -0,0 +1,116 @@
+package timing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const timingsKey string = "keas.timings"
+
+// Phase is the time spent in a named part of a request, ie: db, policy or publish
+type Phase struct {
+	Name        string
+	Description string
+	Duration    time.Duration
+}
+
+type timings struct {
+	phases []*Phase
+	mutex  *sync.Mutex
+}
+
+// Start begins timing the named phase, the returned function stops it. Phases with the same name are
+// summed so that repeated calls, ie: several queries, are reported as one
+func Start(c *fiber.Ctx, name string) func() {
+	start := time.Now()
+	return func() {
+		Add(c, name, time.Since(start))
+	}
+}
+
+// Add records the duration against the named phase
+func Add(c *fiber.Ctx, name string, duration time.Duration) {
+	AddWithDescription(c, name, "", duration)
+}
+
+// AddWithDescription records the duration against the named phase with a description for Server-Timing
+func AddWithDescription(c *fiber.Ctx, name string, description string, duration time.Duration) {
+	recorded := getTimings(c)
+	recorded.mutex.Lock()
+	defer recorded.mutex.Unlock()
+
+	for _, phase := range recorded.phases {
+		if phase.Name == name {
+			phase.Duration += duration
+			if description != "" {
+				phase.Description = description
+			}
+			return
+		}
+	}
+
+	recorded.phases = append(recorded.phases, &Phase{
+		Name:        name,
+		Description: description,
+		Duration:    duration,
+	})
+}
+
+// Get returns the phases recorded for the request in the order they were first recorded
+func Get(c *fiber.Ctx) []Phase {
+	recorded, found := c.Locals(timingsKey).(*timings)
+	if !found {
+		return []Phase{}
+	}
+
+	recorded.mutex.Lock()
+	defer recorded.mutex.Unlock()
+	phases := make([]Phase, len(recorded.phases))
+	for i, phase := range recorded.phases {
+		phases[i] = *phase
+	}
+	return phases
+}
+
+// Milliseconds returns the duration in fractional milliseconds
+func Milliseconds(duration time.Duration) float64 {
+	return float64(duration.Nanoseconds()) / float64(time.Millisecond)
+}
+
+// Header formats the phases as a Server-Timing header value
+func Header(phases []Phase) string {
+	values := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		value := fmt.Sprintf("%s;dur=%s", sanitizeName(phase.Name), strconv.FormatFloat(Milliseconds(phase.Duration), 'f', 3, 64))
+		if phase.Description != "" {
+			value += ";desc=" + strconv.Quote(phase.Description)
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func getTimings(c *fiber.Ctx) *timings {
+	recorded, found := c.Locals(timingsKey).(*timings)
+	if !found {
+		recorded = &timings{mutex: &sync.Mutex{}}
+		c.Locals(timingsKey, recorded)
+	}
+	return recorded
+}
+
+// sanitizeName replaces the characters that aren't valid in a Server-Timing metric name
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,56 @@
+package timing
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHeaderFormat(t *testing.T) {
+	header := Header([]Phase{
+		{Name: "db", Duration: 1500 * time.Microsecond},
+		{Name: "cache miss", Description: `lookup "orders"`, Duration: 250 * time.Microsecond},
+		{Name: "total", Duration: 12 * time.Millisecond},
+	})
+
+	expected := `db;dur=1.500, cache_miss;dur=0.250;desc="lookup \"orders\"", total;dur=12.000`
+	if header != expected {
+		t.Errorf("expected %s, got %s", expected, header)
+	}
+
+	if header := Header([]Phase{}); header != "" {
+		t.Errorf("expected an empty header without phases, got %q", header)
+	}
+}
+
+func TestPhasesWithTheSameNameAreSummed(t *testing.T) {
+	var phases []Phase
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		Add(c, "db", time.Millisecond)
+		Add(c, "policy", time.Millisecond)
+		AddWithDescription(c, "db", "queries", 2*time.Millisecond)
+		phases = Get(c)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(phases) != 2 || phases[0].Name != "db" || phases[1].Name != "policy" {
+		t.Fatalf("expected the phases in the order they were first recorded, got %v", phases)
+	}
+	if phases[0].Duration != 3*time.Millisecond || phases[0].Description != "queries" {
+		t.Errorf("expected the db phase to be summed with its description, got %v", phases[0])
+	}
+}
+
+func TestMilliseconds(t *testing.T) {
+	if ms := Milliseconds(1234567 * time.Nanosecond); ms != 1.234567 {
+		t.Errorf("expected fractional milliseconds, got %v", ms)
+	}
+}